@@ -0,0 +1,16 @@
+package aa
+
+type node struct {
+	level int
+	key   int
+	value interface{}
+	left  *node
+	right *node
+}
+
+func level(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return n.level
+}
@@ -0,0 +1,202 @@
+/*
+	Package aa provides an implementation of AA Tree.
+	Original implementation is available from http://user.it.uu.se/~arneanka/papers/simp.pdf.
+
+	An AA tree is a red-black tree where red nodes may only be right
+	children, so it is balanced with two operations: skew and split.
+*/
+package aa
+
+import (
+	"fmt"
+)
+
+const (
+	lt = -1
+	eq = 0
+	gt = 1
+)
+
+// Tree implements an AA tree.
+type Tree struct {
+	root  *node
+	count int
+}
+
+// New returns a reference to an empty Tree.
+func New() *Tree {
+	return &Tree{
+		root:  nil,
+		count: 0,
+	}
+}
+
+// Count returns num of nodes.
+func (t *Tree) Count() int {
+	return t.count
+}
+
+// Search returns a value associated with a given key.
+// If no value is found by the key, returns nil with an error.
+func (t *Tree) Search(key int) (interface{}, error) {
+	x := t.root
+
+	for x != nil {
+		cmp := compare(key, x.key)
+		switch cmp {
+		case eq:
+			return x.value, nil
+		case lt:
+			x = x.left
+		case gt:
+			x = x.right
+		}
+	}
+	return nil, fmt.Errorf("found no value by key '%v'", key)
+}
+
+// Insert a value with a given key.
+// If the same key has already inserted, the new value overrides old one.
+func (t *Tree) Insert(key int, value interface{}) {
+	t.root = t.insert(t.root, key, value)
+}
+
+func (t *Tree) insert(n *node, key int, value interface{}) *node {
+	if n == nil {
+		t.count++
+		return &node{
+			level: 1,
+			key:   key,
+			value: value,
+		}
+	}
+
+	switch compare(key, n.key) {
+	case eq:
+		n.value = value
+		return n
+	case lt:
+		n.left = t.insert(n.left, key, value)
+	case gt:
+		n.right = t.insert(n.right, key, value)
+	}
+	return split(skew(n))
+}
+
+// Delete remove a node by a given key.
+// If the key does not found, do nothing.
+func (t *Tree) Delete(key int) {
+	t.root = t.delete(t.root, key)
+}
+
+func (t *Tree) delete(n *node, key int) *node {
+	if n == nil {
+		return nil
+	}
+
+	switch compare(key, n.key) {
+	case lt:
+		n.left = t.delete(n.left, key)
+	case gt:
+		n.right = t.delete(n.right, key)
+	case eq:
+		if n.left == nil && n.right == nil {
+			t.count--
+			return nil
+		}
+		// replace n by its neighbour, which is always in a leaf
+		if n.left == nil {
+			x := min(n.right)
+			n.key, n.value = x.key, x.value
+			n.right = t.delete(n.right, x.key)
+		} else {
+			x := max(n.left)
+			n.key, n.value = x.key, x.value
+			n.left = t.delete(n.left, x.key)
+		}
+	}
+
+	// decrease the level and rebalance the level of n
+	if l := 1 + minInt(level(n.left), level(n.right)); l < n.level {
+		n.level = l
+		if l < level(n.right) {
+			n.right.level = l
+		}
+	}
+	n = skew(n)
+	n.right = skew(n.right)
+	if n.right != nil {
+		n.right.right = skew(n.right.right)
+	}
+	n = split(n)
+	n.right = split(n.right)
+	return n
+}
+
+// skew removes a left horizontal link by a right rotation.
+func skew(n *node) *node {
+	if n == nil || level(n.left) != n.level {
+		return n
+	}
+	x := n.left
+	n.left = x.right
+	x.right = n
+	return x
+}
+
+// split removes two consecutive right horizontal links by a left rotation
+// and raises the level of the new parent.
+func split(n *node) *node {
+	if n == nil || n.right == nil || level(n.right.right) != n.level {
+		return n
+	}
+	x := n.right
+	n.right = x.left
+	x.left = n
+	x.level++
+	return x
+}
+
+func min(n *node) *node {
+	for n.left != nil {
+		n = n.left
+	}
+	return n
+}
+
+func max(n *node) *node {
+	for n.right != nil {
+		n = n.right
+	}
+	return n
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Ascend calls f for each key-value pair in ascending order of keys.
+// If f returns false, Ascend stops the iteration.
+func (t *Tree) Ascend(f func(key int, value interface{}) bool) {
+	ascend(t.root, f)
+}
+
+func ascend(n *node, f func(int, interface{}) bool) bool {
+	if n == nil {
+		return true
+	}
+	return ascend(n.left, f) && f(n.key, n.value) && ascend(n.right, f)
+}
+
+func compare(k1, k2 int) int {
+	if k1 < k2 {
+		return lt
+	}
+	if k1 > k2 {
+		return gt
+	}
+	return eq
+}
@@ -0,0 +1,12 @@
+package aa_test
+
+import (
+	"testing"
+
+	"github.com/masa-suzu/gtree/aa"
+	"github.com/masa-suzu/gtree/modeltest"
+)
+
+func TestInvariants(t *testing.T) {
+	modeltest.Run(t, func() modeltest.KVS { return aa.New() }, nil)
+}
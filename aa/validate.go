@@ -0,0 +1,60 @@
+package aa
+
+import "fmt"
+
+// Validate checks the invariants of the tree: keys are in order, Count
+// matches num of nodes, leaves are at level 1, a left child is one level
+// lower than its parent, a right child is at the same or one lower level,
+// a right grandchild is lower than its grandparent, and a node above
+// level 1 has two children.
+// It returns an error for the first violation in preorder.
+func (t *Tree) Validate() error {
+	n, err := validate(t.root, nil, nil)
+	if err != nil {
+		return err
+	}
+	if n != t.count {
+		return fmt.Errorf("aa: Count() is %v, but the tree has %v nodes", t.count, n)
+	}
+	return nil
+}
+
+// validate checks n, whose keys must be in (lo, hi), and returns num of
+// nodes.
+func validate(n *node, lo, hi *int) (int, error) {
+	if n == nil {
+		return 0, nil
+	}
+
+	if lo != nil && n.key <= *lo {
+		return 0, fmt.Errorf("aa: key %v must be greater than %v", n.key, *lo)
+	}
+	if hi != nil && n.key >= *hi {
+		return 0, fmt.Errorf("aa: key %v must be less than %v", n.key, *hi)
+	}
+	if n.left == nil && n.right == nil && n.level != 1 {
+		return 0, fmt.Errorf("aa: leaf %v must be at level 1, got %v", n.key, n.level)
+	}
+	if level(n.left) != n.level-1 {
+		return 0, fmt.Errorf("aa: left child of %v must be one level lower", n.key)
+	}
+	if l := level(n.right); l != n.level && l != n.level-1 {
+		return 0, fmt.Errorf("aa: right child of %v must be at the same or one lower level", n.key)
+	}
+	if n.right != nil && level(n.right.right) >= n.level {
+		return 0, fmt.Errorf("aa: right grandchild of %v must be lower", n.key)
+	}
+	if n.level > 1 && (n.left == nil || n.right == nil) {
+		return 0, fmt.Errorf("aa: %v above level 1 must have two children", n.key)
+	}
+
+	l, err := validate(n.left, lo, &n.key)
+	if err != nil {
+		return 0, err
+	}
+	r, err := validate(n.right, &n.key, hi)
+	if err != nil {
+		return 0, err
+	}
+	return 1 + l + r, nil
+}
@@ -0,0 +1,128 @@
+package avl
+
+// Cursor points at an entry of a Tree, or past its ends.
+// It keeps the ancestors of the entry in an array, or moves by parent
+// pointers with the gtree_parent build tag, so that Next and Prev take
+// O(1) amortized time without allocations:
+//
+//	for c := tree.First(); c.Valid(); c.Next() {
+//		fmt.Println(c.Key(), c.Value())
+//	}
+//
+// A cursor is invalidated by Insert and Delete on its tree.
+type Cursor struct {
+	n  *node
+	up ancestors
+}
+
+// First returns a cursor at the entry of the smallest key.
+func (t *Tree) First() Cursor {
+	c := Cursor{}
+	if t.root != nil {
+		c.n = c.leftmost(t.root)
+	}
+	return c
+}
+
+// Last returns a cursor at the entry of the largest key.
+func (t *Tree) Last() Cursor {
+	c := Cursor{}
+	if t.root != nil {
+		c.n = c.rightmost(t.root)
+	}
+	return c
+}
+
+// Seek returns a cursor at the entry of the smallest key not less than key.
+func (t *Tree) Seek(key int) Cursor {
+	c := Cursor{}
+	d := 0
+	for n := t.root; n != nil; {
+		if n.key < key {
+			c.up.push(n)
+			n = n.right
+		} else {
+			c.n = n
+			d = c.up.depth()
+			c.up.push(n)
+			n = n.left
+		}
+	}
+	c.up.truncate(d)
+	return c
+}
+
+// Valid reports whether the cursor points at an entry.
+func (c *Cursor) Valid() bool {
+	return c.n != nil
+}
+
+// Key returns the key of the entry. The cursor must be valid.
+func (c *Cursor) Key() int {
+	return c.n.key
+}
+
+// Value returns the value of the entry. The cursor must be valid.
+func (c *Cursor) Value() interface{} {
+	return c.n.value
+}
+
+// Next moves the cursor to the next entry in ascending order of keys,
+// and reports whether it is valid.
+func (c *Cursor) Next() bool {
+	n := c.n
+	if n == nil {
+		return false
+	}
+	if n.right != nil {
+		c.up.push(n)
+		c.n = c.leftmost(n.right)
+		return true
+	}
+	p := c.up.pop(n)
+	for p != nil && p.right == n {
+		n, p = p, c.up.pop(p)
+	}
+	c.n = p
+	return p != nil
+}
+
+// Prev moves the cursor to the previous entry in ascending order of keys,
+// and reports whether it is valid.
+func (c *Cursor) Prev() bool {
+	n := c.n
+	if n == nil {
+		return false
+	}
+	if n.left != nil {
+		c.up.push(n)
+		c.n = c.rightmost(n.left)
+		return true
+	}
+	p := c.up.pop(n)
+	for p != nil && p.left == n {
+		n, p = p, c.up.pop(p)
+	}
+	c.n = p
+	return p != nil
+}
+
+// leftmost returns the leftmost node of the subtree of n, pushing the
+// nodes above it.
+func (c *Cursor) leftmost(n *node) *node {
+	for n.left != nil {
+		c.up.push(n)
+		n = n.left
+	}
+	return n
+}
+
+// rightmost returns the rightmost node of the subtree of n, pushing the
+// nodes above it.
+func (c *Cursor) rightmost(n *node) *node {
+	for n.right != nil {
+		c.up.push(n)
+		n = n.right
+	}
+	return n
+}
@@ -0,0 +1,54 @@
+package avl
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/masa-suzu/gtree/internal/dot"
+)
+
+// DOTOptions configures WriteDOT.
+type DOTOptions struct {
+	// Name is the name of the graph. It defaults to "avl".
+	Name string
+	// Values adds values to the labels of nodes.
+	Values bool
+	// Search, if not nil, highlights the path of a search for *Search.
+	Search *int
+}
+
+// WriteDOT writes the tree in the Graphviz DOT language.
+// Each node is labeled with its key, height and balance factor.
+// Invisible placeholders stand for missing children, so that a single
+// child is drawn on its own side.
+func (t *Tree) WriteDOT(w io.Writer, opts *DOTOptions) error {
+	if opts == nil {
+		opts = &DOTOptions{}
+	}
+	name := opts.Name
+	if name == "" {
+		name = "avl"
+	}
+
+	return dot.Write(w, name, dotNode(t.root, opts.Values), opts.Search)
+}
+
+// dotNode converts the subtree of n for the dot package.
+func dotNode(n *node, values bool) *dot.Node {
+	if n == nil {
+		return nil
+	}
+
+	label := fmt.Sprintf("%v", n.key)
+	if values {
+		label += fmt.Sprintf("/%v", n.value)
+	}
+	d := &dot.Node{
+		Key:   n.key,
+		Label: label + fmt.Sprintf("\nh=%v b=%v", n.height, bias(n)),
+	}
+	if n.left != nil || n.right != nil {
+		d.Children = []*dot.Node{dotNode(n.left, values), dotNode(n.right, values)}
+	}
+	return d
+}
@@ -0,0 +1,12 @@
+package avl_test
+
+import (
+	"testing"
+
+	"github.com/masa-suzu/gtree/avl"
+	"github.com/masa-suzu/gtree/internal/fuzztest"
+)
+
+func FuzzDifferential(f *testing.F) {
+	fuzztest.Run(f, func() fuzztest.Tree { return avl.New() })
+}
@@ -0,0 +1,37 @@
+package avl
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/masa-suzu/gtree/internal/htmltree"
+)
+
+// WriteHTML writes the tree as nested lists in a <div class="tree">,
+// which is styled by htmltree.CSS, the style sheet of WriteHTMLPage.
+// Each node is labeled "key/value" with its height and balance factor
+// in the tooltip. Keys and values are escaped.
+func (t *Tree) WriteHTML(w io.Writer) error {
+	return htmltree.Write(w, view(t.root))
+}
+
+// WriteHTMLPage writes a standalone HTML page drawing the tree.
+func (t *Tree) WriteHTMLPage(w io.Writer, title string) error {
+	return htmltree.WritePage(w, title, view(t.root))
+}
+
+func view(n *node) *htmltree.Node {
+	if n == nil {
+		return nil
+	}
+
+	v := &htmltree.Node{
+		Label: fmt.Sprintf("%v/%v", n.key, n.value),
+		Class: "black",
+		Title: fmt.Sprintf("h=%v b=%v", height(n), bias(n)),
+	}
+	if n.left != nil || n.right != nil {
+		v.Children = []*htmltree.Node{view(n.left), view(n.right)}
+	}
+	return v
+}
@@ -0,0 +1,96 @@
+package avl
+
+import (
+	"fmt"
+)
+
+// IntTree implements an AVL tree mapping int keys to int values.
+// Values are stored inline in nodes instead of as interface{}, so Search
+// never allocates and Insert allocates only the node of a new key.
+// It is generated from the same template as Tree, but has no recorder,
+// observer, arena or parent pointers.
+type IntTree struct {
+	root       *intNode
+	count      int
+	needUpdate bool
+	path       []intStep
+}
+
+type intNode struct {
+	height int
+	key    int
+	value  int
+	left   *intNode
+	right  *intNode
+}
+
+// NewInt returns a reference to an empty IntTree.
+func NewInt() *IntTree {
+	return &IntTree{}
+}
+
+// Count returns num of nodes.
+func (t *IntTree) Count() int {
+	return t.count
+}
+
+// Search returns a value associated with a given key.
+// If no value is found by the key, returns false.
+func (t *IntTree) Search(key int) (int, bool) {
+	if n := t.find(key); n != nil {
+		return n.value, true
+	}
+	return 0, false
+}
+
+// Insert a value with a given key.
+// If the same key has already inserted, the new value overrides old one.
+func (t *IntTree) Insert(key, value int) {
+	t.insert(key, value)
+}
+
+// Delete remove a node by a given key.
+// If the key does not found, do nothing.
+func (t *IntTree) Delete(key int) {
+	t.delete(key)
+}
+
+// Ascend calls f for each key-value pair in ascending order of keys.
+// If f returns false, Ascend stops the iteration.
+func (t *IntTree) Ascend(f func(key, value int) bool) {
+	intAscend(t.root, f)
+}
+
+// Validate checks the invariants of the tree as Tree.Validate does.
+func (t *IntTree) Validate() error {
+	n, err := intValidate(t.root, nil, "", nil, nil)
+	if err != nil {
+		return err
+	}
+	if n != t.count {
+		return fmt.Errorf("avl: Count() is %v, but the tree has %v nodes", t.count, n)
+	}
+	return nil
+}
+
+// The hooks of the template do nothing for IntTree.
+
+func (t *IntTree) newNode() *intNode {
+	return &intNode{}
+}
+
+func (t *IntTree) release(n *intNode) {}
+
+func (t *IntTree) record(kind EventKind, n *intNode) {}
+
+func (t *IntTree) rotated(kind EventKind, n *intNode) {}
+
+func (n *intNode) parent() *intNode {
+	return nil
+}
+
+func (n *intNode) setParent(p *intNode) {}
+
+func (n *intNode) linked(p *intNode) bool {
+	return true
+}
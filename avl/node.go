@@ -1,9 +1,41 @@
 package avl
 
 type node struct {
+	up     link // first, as it is empty without the gtree_parent tag
 	height int
 	key    int
 	value  interface{}
 	left   *node
 	right  *node
 }
+
+// arena allocates nodes from chunks and recycles deleted nodes.
+type arena struct {
+	size  int
+	chunk []node
+	free  *node // linked by left
+}
+
+func newArena(size int) *arena {
+	return &arena{size: size}
+}
+
+func (a *arena) alloc() *node {
+	if n := a.free; n != nil {
+		a.free = n.left
+		n.left = nil
+		return n
+	}
+	if len(a.chunk) == 0 {
+		a.chunk = make([]node, a.size)
+	}
+	n := &a.chunk[0]
+	a.chunk = a.chunk[1:]
+	return n
+}
+
+// release recycles n, clearing its value for the GC.
+func (a *arena) release(n *node) {
+	*n = node{left: a.free}
+	a.free = n
+}
@@ -0,0 +1,111 @@
+package avl
+
+import (
+	"fmt"
+)
+
+// EventKind is a kind of structural change made by an operation.
+type EventKind int
+
+// Kinds of events.
+const (
+	NodeCreated    EventKind = iota // a node is inserted as a leaf
+	NodeRemoved                     // a node with at most one child is unlinked
+	RotateLeft                      // a subtree is rotated left
+	RotateRight                     // a subtree is rotated right
+	HeightChanged                   // the height of a node is updated without rotation
+	MaxSubstituted                  // a deleted key is replaced by the max key of its left subtree
+)
+
+var eventNames = [...]string{
+	NodeCreated:    "node created",
+	NodeRemoved:    "node removed",
+	RotateLeft:     "rotateLeft",
+	RotateRight:    "rotateRight",
+	HeightChanged:  "height changed",
+	MaxSubstituted: "deleteMax substitution",
+}
+
+func (k EventKind) String() string {
+	if k < 0 || int(k) >= len(eventNames) {
+		return fmt.Sprintf("EventKind(%d)", int(k))
+	}
+	return eventNames[k]
+}
+
+// Event is a structural change made by an operation.
+type Event struct {
+	Kind EventKind
+	// Key is the key of the changed node.
+	// For a rotation, it is the key of the new root of the subtree.
+	Key int
+	// Height is the height of the node after the change.
+	Height int
+	// Subtree is a copy of the changed subtree after the change.
+	// It is an empty tree for NodeRemoved.
+	Subtree *Tree
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%v at %v (h=%v)", e.Kind, e.Key, e.Height)
+}
+
+// Recorder records the events of operations on a Tree.
+// Operations on a tree run as usual, but each of them copies the changed
+// subtrees, so record only small trees, e.g. for visualization.
+type Recorder struct {
+	Events []Event
+}
+
+// Reset forgets recorded events.
+func (r *Recorder) Reset() {
+	r.Events = nil
+}
+
+// SetRecorder starts recording events into r.
+// If r is nil, the tree stops recording.
+func (t *Tree) SetRecorder(r *Recorder) {
+	t.rec = r
+}
+
+func (t *Tree) record(kind EventKind, n *node) {
+	if t.rec != nil {
+		t.rec.add(kind, n)
+	}
+}
+
+func (r *Recorder) add(kind EventKind, n *node) {
+	var sub *node
+	if kind != NodeRemoved {
+		sub = copyNode(n)
+	}
+	r.Events = append(r.Events, Event{
+		Kind:    kind,
+		Key:     n.key,
+		Height:  height(n),
+		Subtree: &Tree{root: sub, count: size(sub)},
+	})
+}
+
+// copyNode returns a copy of the subtree of n, whose root has no parent.
+func copyNode(n *node) *node {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	c.setParent(nil)
+	if c.left = copyNode(n.left); c.left != nil {
+		c.left.setParent(&c)
+	}
+	if c.right = copyNode(n.right); c.right != nil {
+		c.right.setParent(&c)
+	}
+	return &c
+}
+
+func size(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return 1 + size(n.left) + size(n.right)
+}
@@ -0,0 +1,167 @@
+package avl
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// The recursive implementation of insert and delete, which threads the
+// balance state through t.needUpdate, is kept as the reference of the
+// iterative one.
+
+func (t *Tree) insertRecursive(n *node, key int, value interface{}) *node {
+	if n == nil {
+		t.needUpdate = true
+		t.count++
+		n = t.newNode()
+		n.height = 1
+		n.key = key
+		n.value = value
+		t.record(NodeCreated, n)
+		return n
+	}
+
+	cmp := compare(key, n.key)
+	switch cmp {
+	case lt:
+		n.left = t.insertRecursive(n.left, key, value)
+		return t.balanceLeft(n)
+	case eq:
+		n.value = value
+		return n
+	case gt:
+		t.needUpdate = false
+		n.right = t.insertRecursive(n.right, key, value)
+		return t.balanceRight(n)
+	}
+
+	return nil
+}
+
+func (t *Tree) deleteRecursive(n *node, key int) *node {
+	if n == nil {
+		t.needUpdate = false
+		return nil
+	}
+
+	cmp := compare(key, n.key)
+	switch cmp {
+	case lt:
+		n.left = t.deleteRecursive(n.left, key)
+		return t.balanceRight(n)
+	case gt:
+		n.right = t.deleteRecursive(n.right, key)
+		return t.balanceLeft(n)
+	case eq:
+		t.count--
+		if n.left == nil {
+			t.needUpdate = true
+			t.record(NodeRemoved, n)
+			r := n.right
+			t.release(n)
+			return r
+		} else {
+			var key int
+			var value interface{}
+			n.left, key, value = t.deleteMaxRecursive(n.left)
+			n.key = key
+			n.value = value
+			t.record(MaxSubstituted, n)
+			return t.balanceRight(n)
+		}
+	}
+	panic("unknown switch case")
+
+}
+
+// deleteMaxRecursive returns n without its max node, and the entry of
+// the max node.
+func (t *Tree) deleteMaxRecursive(n *node) (*node, int, interface{}) {
+	if n.right != nil {
+		var key int
+		var value interface{}
+		n.right, key, value = t.deleteMaxRecursive(n.right)
+		return t.balanceLeft(n), key, value
+	}
+
+	t.needUpdate = true
+	key, value := n.key, n.value
+	t.record(NodeRemoved, n)
+	l := n.left
+	t.release(n)
+	return l, key, value
+}
+
+// shape returns the keys and heights of n in preorder.
+func shape(n *node) string {
+	if n == nil {
+		return "."
+	}
+	return fmt.Sprintf("(%v:%v/%v %v %v)", n.key, n.value, n.height, shape(n.left), shape(n.right))
+}
+
+func TestIterative_SameShapes(t *testing.T) {
+	for _, keys := range []int{8, 64, 300} {
+		t.Run(fmt.Sprint(keys), func(t *testing.T) {
+			iterative, recursive := New(), New()
+			ri, rr := &Recorder{}, &Recorder{}
+			iterative.SetRecorder(ri)
+			recursive.SetRecorder(rr)
+
+			r := rand.New(rand.NewSource(int64(keys)))
+			for i := 0; i < 3000; i++ {
+				k := r.Intn(keys)
+				op := fmt.Sprintf("Insert(%v)", k)
+				if r.Intn(3) == 0 {
+					op = fmt.Sprintf("Delete(%v)", k)
+					iterative.Delete(k)
+					recursive.root = recursive.deleteRecursive(recursive.root, k)
+				} else {
+					iterative.Insert(k, i)
+					recursive.root = recursive.insertRecursive(recursive.root, k, i)
+				}
+
+				if got, want := shape(iterative.root), shape(recursive.root); got != want {
+					t.Fatalf("step %v: %v: shapes differ\nwant %v\ngot  %v", i, op, want, got)
+				}
+				if iterative.Count() != recursive.Count() {
+					t.Fatalf("step %v: %v: want %v nodes, got %v", i, op, recursive.Count(), iterative.Count())
+				}
+				if got, want := fmt.Sprint(ri.Events), fmt.Sprint(rr.Events); got != want {
+					t.Fatalf("step %v: %v: events differ\nwant %v\ngot  %v", i, op, want, got)
+				}
+				ri.Reset()
+				rr.Reset()
+			}
+		})
+	}
+}
+
+func BenchmarkInsertDelete_400000_Iterative(b *testing.B) {
+	keys := rand.New(rand.NewSource(1)).Perm(400000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree := New()
+		for _, k := range keys {
+			tree.Insert(k, nil)
+		}
+		for _, k := range keys {
+			tree.Delete(k)
+		}
+	}
+}
+
+func BenchmarkInsertDelete_400000_Recursive(b *testing.B) {
+	keys := rand.New(rand.NewSource(1)).Perm(400000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree := New()
+		for _, k := range keys {
+			tree.root = tree.insertRecursive(tree.root, k, nil)
+		}
+		for _, k := range keys {
+			tree.root = tree.deleteRecursive(tree.root, k)
+		}
+	}
+}
@@ -1,30 +1,37 @@
 /*
 	Package avl provides an implementation of AVL Tree.
+
+	Nodes keep no parent pointers by default, so a Cursor keeps the
+	ancestors of its entry in an explicit stack: a fixed array of 92 nodes,
+	enough for any AVL tree of less than 1<<63 nodes, which makes a Cursor
+	about 750 bytes. With the gtree_parent build tag, every node keeps
+	a pointer to its parent instead, and a Cursor holds only its node.
 */
 package avl
 
 import (
 	"fmt"
-	"math"
 )
 
+//go:generate go run ../internal/gentree -in tree.tmpl -out tree_gen.go -tree Tree -node node -value interface{}
+//go:generate go run ../internal/gentree -in tree.tmpl -out inttree_gen.go -tree IntTree -node intNode -value int -prefix int
+
 const (
 	lt = -1
 	eq = 0
 	gt = 1
 )
 
-type kvp struct {
-	key   int
-	value interface{}
-}
-
 // Tree implements an AVL tree.
 type Tree struct {
 	root       *node
 	count      int
 	needUpdate bool
-	max        *kvp
+	path       []step
+	rec        *Recorder
+	obs        Observer
+	rotations  int
+	arena      *arena
 }
 
 // New returns a reference to an empty Tree.
@@ -35,6 +42,27 @@ func New() *Tree {
 	}
 }
 
+// NewWithArena returns a reference to an empty Tree allocating nodes
+// from chunks of chunkSize nodes and recycling deleted nodes, which cuts
+// allocations and the work of the GC for trees under churn.
+// A chunk is kept alive as long as any of its nodes is in the tree.
+func NewWithArena(chunkSize int) (*Tree, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("chunk size must be positive, got %v", chunkSize)
+	}
+	return &Tree{arena: newArena(chunkSize)}, nil
+}
+
+// Reset removes all nodes at once.
+// A tree with an arena drops its chunks and allocates new ones.
+func (t *Tree) Reset() {
+	t.root = nil
+	t.count = 0
+	if t.arena != nil {
+		t.arena = newArena(t.arena.size)
+	}
+}
+
 // Count returns num of nodes.
 func (t *Tree) Count() int {
 	return t.count
@@ -43,135 +71,22 @@ func (t *Tree) Count() int {
 // Insert a value with a given key.
 // If the same key has already inserted, the new value overrides old one.
 func (t *Tree) Insert(key int, value interface{}) {
-	t.root = t.insert(t.root, key, value)
-}
-
-func (t *Tree) insert(n *node, key int, value interface{}) *node {
-	if n == nil {
-		t.needUpdate = true
-		t.count++
-		return &node{
-			height: 1,
-			key:    key,
-			value:  value,
-		}
-	}
-
-	cmp := compare(key, n.key)
-	switch cmp {
-	case lt:
-		n.left = t.insert(n.left, key, value)
-		return t.balanceLeft(n)
-	case eq:
-		n.value = value
-		return n
-	case gt:
-		t.needUpdate = false
-		n.right = t.insert(n.right, key, value)
-		return t.balanceRight(n)
-	}
-
-	return nil
-}
-
-func (t *Tree) balanceLeft(n *node) *node {
-	if !t.needUpdate {
-		return n
-	}
-
-	h := height(n)
-	if bias(n) == 2 {
-		if bias(n.left) >= 0 {
-			n = rotateRight(n)
-		} else {
-			n = rotateLeftRight(n)
-		}
-	} else {
-		modifyHeight(n)
-	}
-	t.needUpdate = h != height(n)
-	return n
-}
-
-func (t *Tree) balanceRight(n *node) *node {
-	if !t.needUpdate {
-		return n
+	old, present := t.insert(key, value)
+	if t.obs == nil {
+		return
 	}
 
-	h := height(n)
-	if bias(n) == 2 {
-		if bias(n.right) <= 0 {
-			n = rotateLeft(n)
-		} else {
-			n = rotateRightLeft(n)
-		}
-	} else {
-		modifyHeight(n)
+	if present {
+		t.obs.OnUpdate(key, old, value)
 	}
-	t.needUpdate = h != height(n)
-	return n
-}
-
-func rotateLeftRight(n *node) *node {
-	n.left = rotateLeft(n.left)
-	return rotateRight(n)
-}
-func rotateRightLeft(n *node) *node {
-	n.right = rotateLeft(n.right)
-	return rotateLeft(n)
-}
-
-func height(n *node) int {
-	if n == nil {
-		return 0
-	}
-
-	return n.height
-}
-
-func bias(n *node) int {
-	return height(n.left) - height(n.right)
-}
-
-func modifyHeight(n *node) {
-	n.height = 1 + int(math.Max(float64(height(n.left)), float64(height(n.right))))
-}
-
-func rotateLeft(v *node) *node {
-	u := v.right
-	n := u.left
-	u.left = v
-	v.right = n
-	modifyHeight(u.left)
-	modifyHeight(u)
-	return u
-}
-
-func rotateRight(u *node) *node {
-	v := u.left
-	n := v.right
-	v.right = u
-	u.left = n
-	modifyHeight(v.right)
-	modifyHeight(v)
-	return v
+	t.obs.OnInsert(key, !present)
 }
 
 // Search returns a value associated with a given key.
 // If no value is found by the key, returns nil with an error.
 func (t *Tree) Search(key int) (interface{}, error) {
-	x := t.root
-
-	for x != nil {
-		cmp := compare(key, x.key)
-		switch cmp {
-		case eq:
-			return x.value, nil
-		case lt:
-			x = x.left
-		case gt:
-			x = x.right
-		}
+	if n := t.find(key); n != nil {
+		return n.value, nil
 	}
 	return nil, fmt.Errorf("found no value by key '%v'", key)
 }
@@ -179,52 +94,40 @@ func (t *Tree) Search(key int) (interface{}, error) {
 // Delete remove a node by a given key.
 // If the key does not found, do nothing.
 func (t *Tree) Delete(key int) {
-	t.root = t.delete(t.root, key)
+	old, present := t.delete(key)
+	if t.obs != nil && present {
+		t.obs.OnDelete(key, old)
+	}
 }
 
-func (t *Tree) delete(n *node, key int) *node {
-	if n == nil {
-		t.needUpdate = false
-		return nil
+func (t *Tree) newNode() *node {
+	if t.arena != nil {
+		return t.arena.alloc()
 	}
+	return &node{}
+}
 
-	cmp := compare(key, n.key)
-	switch cmp {
-	case lt:
-		n.left = t.delete(n.left, key)
-		return t.balanceRight(n)
-	case gt:
-		n.right = t.delete(n.right, key)
-		return t.balanceLeft(n)
-	case eq:
-		t.count--
-		if n.left == nil {
-			t.needUpdate = true
-			return n.right
-		} else {
-			n.left = t.deleteMax(n.left)
-			n.key = t.max.key
-			n.value = t.max.value
-			return t.balanceRight(n)
-		}
+func (t *Tree) release(n *node) {
+	if t.arena != nil {
+		t.arena.release(n)
 	}
-	panic("unknown switch case")
-
 }
 
-func (t *Tree) deleteMax(n *node) *node {
-	if n.right != nil {
-		n.right = t.deleteMax(n.right)
-		return t.balanceLeft(n)
+// rotated counts a rotation to n and notifies the recorder and the observer.
+func (t *Tree) rotated(kind EventKind, n *node) {
+	t.rotations++
+	t.record(kind, n)
+	if t.obs != nil {
+		t.obs.OnRotate(kind, n.key)
 	}
+}
 
-	t.needUpdate = true
-	t.max = &kvp{
-		key:   n.key,
-		value: n.value,
-	}
-	return n.left
+// Ascend calls f for each key-value pair in ascending order of keys.
+// If f returns false, Ascend stops the iteration.
+func (t *Tree) Ascend(f func(key int, value interface{}) bool) {
+	ascend(t.root, f)
 }
+
 func compare(k1, k2 int) int {
 	if k1 < k2 {
 		return lt
@@ -1,6 +1,11 @@
 package avl_test
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/masa-suzu/gtree/avl"
@@ -137,6 +142,10 @@ func TestDelete(t *testing.T) {
 }
 
 func assertTree(t *testing.T, tree *avl.Tree, kvs []kv) {
+	if err := tree.Validate(); err != nil {
+		t.Error(err)
+	}
+
 	if len(kvs) != tree.Count() {
 		t.Errorf("num of nodes must be %v, got %v", len(kvs), tree.Count())
 	}
@@ -217,3 +226,726 @@ func TestSearch_by_InvalidKey(t *testing.T) {
 	}
 
 }
+
+func TestAscend(t *testing.T) {
+	tests := []struct {
+		name     string
+		inserted []kv
+		stop     int
+		want     []kv
+	}{
+		{
+			name:     "empty",
+			inserted: []kv{},
+			want:     []kv{},
+		},
+		{
+			name: "random-ordering",
+			inserted: []kv{
+				{k: 6, v: 600},
+				{k: 10, v: nil},
+				{k: 1, v: 100},
+				{k: 9, v: 900},
+			},
+			want: []kv{
+				{k: 1, v: 100},
+				{k: 6, v: 600},
+				{k: 9, v: 900},
+				{k: 10, v: nil},
+			},
+		},
+		{
+			name: "stop",
+			inserted: []kv{
+				{k: 3, v: 300},
+				{k: 2, v: 200},
+				{k: 1, v: 100},
+			},
+			stop: 2,
+			want: []kv{
+				{k: 1, v: 100},
+				{k: 2, v: 200},
+			},
+		},
+	}
+
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := avl.New()
+			for _, kv := range tt.inserted {
+				tree.Insert(kv.k, kv.v)
+			}
+
+			got := []kv{}
+			tree.Ascend(func(key int, value interface{}) bool {
+				got = append(got, kv{k: key, v: value})
+				return key != tt.stop
+			})
+
+			if len(tt.want) != len(got) {
+				t.Fatalf("num of nodes must be %v, got %v", len(tt.want), len(got))
+			}
+
+			for i, want := range tt.want {
+				if want != got[i] {
+					t.Errorf("want %v, got %v", want, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestInsertDelete_Random(t *testing.T) {
+	insertDeleteRandom(t, avl.New())
+}
+
+func TestNewWithArena(t *testing.T) {
+	tree, err := avl.NewWithArena(7)
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	insertDeleteRandom(t, tree)
+
+	if _, err := avl.NewWithArena(0); err == nil {
+		t.Errorf("got no error for chunk size 0")
+	}
+}
+
+func TestNewWithArena_Allocs(t *testing.T) {
+	tree, _ := avl.NewWithArena(1024)
+	for i := 0; i < 1000; i++ {
+		tree.Insert(i, nil)
+	}
+
+	// deleted nodes are recycled by inserts
+	i := 0
+	got := testing.AllocsPerRun(100, func() {
+		tree.Delete(i)
+		tree.Insert(i, nil)
+		i = (i + 7) % 1000
+	})
+	if got != 0 {
+		t.Errorf("want no allocations, got %v", got)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Error(err)
+	}
+	if tree.Count() != 1000 {
+		t.Errorf("num of nodes must be 1000, got %v", tree.Count())
+	}
+}
+
+func TestReset(t *testing.T) {
+	plain := avl.New()
+	arena, _ := avl.NewWithArena(16)
+
+	for _, tree := range []*avl.Tree{plain, arena} {
+		for i := 0; i < 100; i++ {
+			tree.Insert(i, i)
+		}
+		tree.Reset()
+		assertTree(t, tree, []kv{})
+
+		tree.Insert(1, 100)
+		assertTree(t, tree, []kv{{k: 1, v: 100}})
+	}
+}
+
+func insertDeleteRandom(t *testing.T, tree *avl.Tree) {
+	t.Helper()
+
+	m := map[int]interface{}{}
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 5000; i++ {
+		k := r.Intn(300)
+		if r.Intn(3) == 0 {
+			tree.Delete(k)
+			delete(m, k)
+		} else {
+			tree.Insert(k, i)
+			m[k] = i
+		}
+
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("step %v: %v", i, err)
+		}
+	}
+
+	kvs := []kv{}
+	for k, v := range m {
+		kvs = append(kvs, kv{k: k, v: v})
+	}
+	assertTree(t, tree, kvs)
+}
+
+func TestWriteDOT(t *testing.T) {
+	search := 4
+
+	tests := []struct {
+		name     string
+		inserted []kv
+		opts     *avl.DOTOptions
+		want     string
+	}{
+		{
+			name:     "zero-node",
+			inserted: []kv{},
+			want: `digraph "avl" {
+	node [shape=circle, fontname="arial"];
+}
+`,
+		},
+		{
+			name: "four-nodes",
+			inserted: []kv{
+				{k: 2, v: "\"x\""},
+				{k: 1, v: 100},
+				{k: 3, v: 300},
+				{k: 4, v: 400},
+			},
+			opts: &avl.DOTOptions{Name: "g", Values: true, Search: &search},
+			want: `digraph "g" {
+	node [shape=circle, fontname="arial"];
+	"n2" [label="2/\"x\"\nh=3 b=-1", style=filled, fillcolor="lightblue"];
+	"n2" -> "n1";
+	"n1" [label="1/100\nh=1 b=0"];
+	"n2" -> "n3" [penwidth=3];
+	"n3" [label="3/300\nh=2 b=-1", style=filled, fillcolor="lightblue"];
+	"n3.0" [style=invis];
+	"n3" -> "n3.0" [style=invis];
+	"n3" -> "n4" [penwidth=3];
+	"n4" [label="4/400\nh=1 b=0", style=filled, fillcolor="lightblue"];
+}
+`,
+		},
+	}
+
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := avl.New()
+			for _, kv := range tt.inserted {
+				tree.Insert(kv.k, kv.v)
+			}
+
+			w := &bytes.Buffer{}
+			if err := tree.WriteDOT(w, tt.opts); err != nil {
+				t.Fatalf("got an error '%v'", err)
+			}
+			if got := w.String(); tt.want != got {
+				t.Errorf("\nwant\n%v\ngot\n%v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		inserted []kv
+		want     string
+	}{
+		{
+			name:     "zero-node",
+			inserted: []kv{},
+			want: `<div class="tree">
+</div>
+`,
+		},
+		{
+			name: "four-nodes",
+			inserted: []kv{
+				{k: 1, v: 100},
+				{k: 2, v: "<b>"},
+				{k: 3, v: 300},
+				{k: 4, v: 400},
+			},
+			want: `<div class="tree">
+<ul>
+<li>
+<span class="black" title="h=3 b=-1">2/&lt;b&gt;</span>
+<ul>
+<li>
+<span class="black" title="h=1 b=0">1/100</span>
+</li>
+<li>
+<span class="black" title="h=2 b=-1">3/300</span>
+<ul>
+<li class="empty"></li>
+<li>
+<span class="black" title="h=1 b=0">4/400</span>
+</li>
+</ul>
+</li>
+</ul>
+</li>
+</ul>
+</div>
+`,
+		},
+	}
+
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := avl.New()
+			for _, kv := range tt.inserted {
+				tree.Insert(kv.k, kv.v)
+			}
+
+			w := &bytes.Buffer{}
+			if err := tree.WriteHTML(w); err != nil {
+				t.Fatalf("got an error '%v'", err)
+			}
+			if got := w.String(); tt.want != got {
+				t.Errorf("\nwant\n%v\ngot\n%v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteHTMLPage(t *testing.T) {
+	tree := avl.New()
+	tree.Insert(1, 100)
+
+	w := &bytes.Buffer{}
+	if err := tree.WriteHTMLPage(w, "avl"); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	got := w.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<title>avl</title>",
+		`<span class="black" title="h=1 b=0">1/100</span>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q in\n%v", want, got)
+		}
+	}
+}
+
+func TestWriteHTML_WriteError(t *testing.T) {
+	tree := avl.New()
+	tree.Insert(1, 100)
+
+	if err := tree.WriteHTML(errWriter{}); err == nil {
+		t.Errorf("got no error")
+	}
+	if err := tree.WriteHTMLPage(errWriter{}, "avl"); err == nil {
+		t.Errorf("got no error")
+	}
+}
+
+func TestWriteDOT_WriteError(t *testing.T) {
+	tree := avl.New()
+	tree.Insert(1, 100)
+
+	if err := tree.WriteDOT(errWriter{}, nil); err != errWrite {
+		t.Errorf("want '%v', got '%v'", errWrite, err)
+	}
+}
+
+var errWrite = errors.New("write error")
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errWrite
+}
+
+func TestFormat(t *testing.T) {
+	tree := avl.New()
+	for _, k := range []int{2, 1, 3, 4} {
+		tree.Insert(k, k*100)
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "%v",
+			want:   "[1:100 2:200 3:300 4:400]",
+		},
+		{
+			format: "%s",
+			want:   "[1:100 2:200 3:300 4:400]",
+		},
+		{
+			format: "%+v",
+			want: `2:200 h=3
+├── 1:100 h=1
+└── 3:300 h=2
+    ├── ∅
+    └── 4:400 h=1
+`,
+		},
+		{
+			format: "%+.0v",
+			want: `2:200 h=3 …
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprintf(tt.format, tree); tt.want != got {
+			t.Errorf("%v: \nwant\n%v\ngot\n%v", tt.format, tt.want, got)
+		}
+	}
+
+	if got := avl.New().String(); got != "[]" {
+		t.Errorf("want [], got %v", got)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	tree := avl.New()
+	r := &avl.Recorder{}
+	tree.SetRecorder(r)
+
+	tree.Insert(1, 100)
+	tree.Insert(2, 200)
+	r.Reset()
+	tree.Insert(3, 300)
+
+	want := []string{
+		"node created at 3 (h=1)",
+		"height changed at 2 (h=2)",
+		"rotateLeft at 2 (h=2)",
+	}
+	assertEvents(t, r.Events, want)
+
+	// the subtree is a copy after the rotation
+	if got := r.Events[2].Subtree.String(); got != "[1:100 2:200 3:300]" {
+		t.Errorf("want [1:100 2:200 3:300], got %v", got)
+	}
+
+	r.Reset()
+	tree.Delete(2)
+	assertEvents(t, r.Events, []string{
+		"node removed at 1 (h=1)",
+		"deleteMax substitution at 1 (h=2)",
+	})
+
+	tree.SetRecorder(nil)
+	tree.Insert(4, 400)
+	if len(r.Events) != 2 {
+		t.Errorf("want no events after SetRecorder(nil), got %v", r.Events)
+	}
+}
+
+func assertEvents(t *testing.T, events []avl.Event, want []string) {
+	t.Helper()
+
+	if len(want) != len(events) {
+		t.Fatalf("want %v, got %v", want, events)
+	}
+	for i := range want {
+		if got := events[i].String(); want[i] != got {
+			t.Errorf("want %v, got %v", want[i], got)
+		}
+	}
+}
+
+type logObserver struct {
+	log []string
+}
+
+func (o *logObserver) OnInsert(key int, new bool) {
+	o.log = append(o.log, fmt.Sprintf("insert %v %v", key, new))
+}
+
+func (o *logObserver) OnUpdate(key int, old, value interface{}) {
+	o.log = append(o.log, fmt.Sprintf("update %v %v->%v", key, old, value))
+}
+
+func (o *logObserver) OnDelete(key int, value interface{}) {
+	o.log = append(o.log, fmt.Sprintf("delete %v %v", key, value))
+}
+
+func (o *logObserver) OnRotate(kind avl.EventKind, pivot int) {
+	o.log = append(o.log, fmt.Sprintf("%v %v", kind, pivot))
+}
+
+func TestObserver(t *testing.T) {
+	tree := avl.New()
+	o := &logObserver{}
+	tree.SetObserver(o)
+
+	tree.Insert(1, 100)
+	tree.Insert(2, 200)
+	tree.Insert(3, 300)
+	tree.Insert(2, "200")
+	tree.Delete(1)
+	tree.Delete(10)
+
+	want := []string{
+		"insert 1 true",
+		"insert 2 true",
+		"rotateLeft 2",
+		"insert 3 true",
+		"update 2 200->200",
+		"insert 2 false",
+		"delete 1 100",
+	}
+	if fmt.Sprint(want) != fmt.Sprint(o.log) {
+		t.Errorf("\nwant %q\ngot  %q", want, o.log)
+	}
+	assertTree(t, tree, []kv{{k: 2, v: "200"}, {k: 3, v: 300}})
+
+	tree.SetObserver(nil)
+	tree.Insert(4, 400)
+	if len(want) != len(o.log) {
+		t.Errorf("want no callbacks after SetObserver(nil), got %q", o.log[len(want):])
+	}
+}
+
+func TestStats(t *testing.T) {
+	tree := avl.New()
+	if got := tree.Stats(); got.Height != 0 || got.Count != 0 || len(got.Depths) != 0 {
+		t.Errorf("want empty stats, got %+v", got)
+	}
+
+	for i := 1; i <= 8; i++ {
+		tree.Insert(i, i*100)
+	}
+
+	//         4
+	//     2       6
+	//   1   3   5   7
+	//                 8
+	got := tree.Stats()
+	want := avl.Stats{
+		Count:        8,
+		Height:       4,
+		MinLeafDepth: 2,
+		MaxLeafDepth: 3,
+		AvgLeafDepth: 2.25,
+		Depths:       []int{1, 2, 4, 1},
+		Rotations:    4,
+	}
+	if fmt.Sprint(want) != fmt.Sprint(got) {
+		t.Errorf("\nwant %+v\ngot  %+v", want, got)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	tree := avl.New()
+	for _, k := range rand.Perm(100) {
+		tree.Insert(2*k, k)
+	}
+
+	want := 0
+	for c := tree.First(); c.Valid(); c.Next() {
+		if c.Key() != want || c.Value() != want/2 {
+			t.Fatalf("want %v:%v, got %v:%v", want, want/2, c.Key(), c.Value())
+		}
+		want += 2
+	}
+	if want != 200 {
+		t.Errorf("want 100 entries, got %v", want/2)
+	}
+
+	for c := tree.Last(); c.Valid(); c.Prev() {
+		want -= 2
+		if c.Key() != want {
+			t.Fatalf("want %v, got %v", want, c.Key())
+		}
+	}
+	if want != 0 {
+		t.Errorf("Prev stopped at %v", want)
+	}
+}
+
+func TestCursor_Seek(t *testing.T) {
+	tree := avl.New()
+	for k := 0; k < 10; k++ {
+		tree.Insert(10*k, nil)
+	}
+
+	tests := []struct {
+		key   int
+		want  int
+		valid bool
+	}{
+		{key: -1, want: 0, valid: true},
+		{key: 0, want: 0, valid: true},
+		{key: 41, want: 50, valid: true},
+		{key: 90, want: 90, valid: true},
+		{key: 91, valid: false},
+	}
+
+	for _, tt := range tests {
+		c := tree.Seek(tt.key)
+		if c.Valid() != tt.valid {
+			t.Fatalf("Seek(%v): want valid %v, got %v", tt.key, tt.valid, c.Valid())
+		}
+		if tt.valid && c.Key() != tt.want {
+			t.Errorf("Seek(%v): want %v, got %v", tt.key, tt.want, c.Key())
+		}
+	}
+
+	c := tree.Seek(45)
+	if !c.Prev() || c.Key() != 40 {
+		t.Errorf("want 40 before 50")
+	}
+	if c := avl.New().First(); c.Valid() || c.Next() || c.Prev() {
+		t.Errorf("got a valid cursor on an empty tree")
+	}
+}
+
+func TestCursor_SeekWalk(t *testing.T) {
+	tree := avl.New()
+	for _, k := range rand.New(rand.NewSource(1)).Perm(300) {
+		tree.Insert(2*k, nil)
+	}
+
+	for key := -1; key <= 600; key++ {
+		first := (key + 1) / 2
+		if key < 0 {
+			first = 0
+		}
+
+		n := 0
+		for c := tree.Seek(key); c.Valid(); c.Next() {
+			if want := 2 * (first + n); c.Key() != want {
+				t.Fatalf("Seek(%v): want %v, got %v", key, want, c.Key())
+			}
+			n++
+		}
+		if n != 300-first {
+			t.Fatalf("Seek(%v): want %v entries after, got %v", key, 300-first, n)
+		}
+
+		c := tree.Seek(key)
+		if !c.Valid() {
+			continue
+		}
+		n = 0
+		for c.Prev() {
+			n++
+		}
+		if n != first {
+			t.Fatalf("Seek(%v): want %v entries before, got %v", key, first, n)
+		}
+	}
+}
+
+func TestCursor_Allocs(t *testing.T) {
+	tree := avl.New()
+	for i := 0; i < 1000; i++ {
+		tree.Insert(i, nil)
+	}
+
+	got := testing.AllocsPerRun(10, func() {
+		for c := tree.First(); c.Valid(); c.Next() {
+		}
+		for c := tree.Seek(500); c.Valid(); c.Prev() {
+		}
+	})
+	if got != 0 {
+		t.Errorf("want no allocations, got %v", got)
+	}
+}
+
+func TestIntTree(t *testing.T) {
+	tree := avl.NewInt()
+	want := map[int]int{}
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 3000; i++ {
+		k := r.Intn(500)
+		if r.Intn(3) == 0 {
+			tree.Delete(k)
+			delete(want, k)
+		} else {
+			tree.Insert(k, i)
+			want[k] = i
+		}
+	}
+
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Count() != len(want) {
+		t.Errorf("num of nodes must be %v, got %v", len(want), tree.Count())
+	}
+	for k := 0; k < 500; k++ {
+		v, ok := tree.Search(k)
+		if w, found := want[k]; ok != found || v != w {
+			t.Errorf("Search(%v): want %v %v, got %v %v", k, w, found, v, ok)
+		}
+	}
+
+	prev := -1
+	tree.Ascend(func(key, value int) bool {
+		if key <= prev || want[key] != value {
+			t.Errorf("got %v:%v after %v", key, value, prev)
+		}
+		prev = key
+		return true
+	})
+}
+
+func TestIntTree_Allocs(t *testing.T) {
+	tree := avl.NewInt()
+	for i := 0; i < 1000; i++ {
+		tree.Insert(i, i)
+	}
+
+	i := 0
+	got := testing.AllocsPerRun(100, func() {
+		tree.Insert(i, i+1)
+		tree.Search(i)
+		tree.Search(-i - 1)
+		i = (i + 7) % 1000
+	})
+	if got != 0 {
+		t.Errorf("want no allocations, got %v", got)
+	}
+
+	// one for the node of a new key
+	got = testing.AllocsPerRun(100, func() {
+		tree.Delete(i)
+		tree.Insert(i, i)
+		i = (i + 7) % 1000
+	})
+	if got != 1 {
+		t.Errorf("want 1 allocation, got %v", got)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	tree := avl.New()
+	for _, k := range rand.Perm(100) {
+		tree.Insert(k, 10*k)
+	}
+
+	index := tree.Freeze()
+	tree.Delete(50)
+
+	if index.Count() != 100 {
+		t.Errorf("num of keys must be 100, got %v", index.Count())
+	}
+	if v, err := index.Search(50); err != nil || v != 500 {
+		t.Errorf("want 500, got %v, %v", v, err)
+	}
+
+	want := 0
+	index.Ascend(func(key int, value interface{}) bool {
+		if key != want || value != 10*want {
+			t.Errorf("want %v:%v, got %v:%v", want, 10*want, key, value)
+		}
+		want++
+		return true
+	})
+	if want != 100 {
+		t.Errorf("want 100 entries, got %v", want)
+	}
+}
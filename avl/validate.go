@@ -0,0 +1,43 @@
+package avl
+
+import (
+	"fmt"
+)
+
+// InvariantError describes the first node which breaks an invariant.
+type InvariantError struct {
+	// Key is the key of the node.
+	Key int
+	// Path is the way from the root to the node,
+	// where 'L' is a left child and 'R' is a right child.
+	Path string
+	// Reason is the broken invariant.
+	Reason string
+}
+
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("avl: key '%v' at path '%v': %v", e.Key, e.Path, e.Reason)
+}
+
+// Validate checks the invariants of the tree: keys are in order, Count
+// matches num of nodes, every stored height is correct and the heights of
+// two subtrees differ by at most one, and parent pointers, if kept by
+// the gtree_parent build tag, link children to their parents.
+// It returns an *InvariantError for the first violation in preorder.
+func (t *Tree) Validate() error {
+	n, err := validate(t.root, nil, "", nil, nil)
+	if err != nil {
+		return err
+	}
+	if n != t.count {
+		return fmt.Errorf("avl: Count() is %v, but the tree has %v nodes", t.count, n)
+	}
+	return nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,97 @@
+package avl
+
+import (
+	"testing"
+)
+
+func TestValidate_Broken(t *testing.T) {
+	tests := []struct {
+		name string
+		tree func() *Tree
+		key  int
+		path string
+	}{
+		{
+			name: "order",
+			tree: func() *Tree {
+				tree := New()
+				tree.Insert(2, nil)
+				tree.Insert(1, nil)
+				tree.Insert(3, nil)
+				tree.root.right.key = 0
+				return tree
+			},
+			key:  0,
+			path: "R",
+		},
+		{
+			name: "height",
+			tree: func() *Tree {
+				tree := New()
+				tree.Insert(2, nil)
+				tree.Insert(1, nil)
+				tree.root.left.height = 2
+				return tree
+			},
+			key:  2,
+			path: "",
+		},
+		{
+			name: "bias",
+			tree: func() *Tree {
+				tree := New()
+				tree.Insert(3, nil)
+				tree.Insert(2, nil)
+				tree.Insert(4, nil)
+				tree.Insert(1, nil)
+				// unlink 4 and fix heights, leaving bias 2 at the root
+				tree.root.right = nil
+				tree.root.height = 3
+				tree.count--
+				return tree
+			},
+			key:  3,
+			path: "",
+		},
+		{
+			name: "parent",
+			tree: func() *Tree {
+				tree := New()
+				tree.Insert(2, nil)
+				tree.Insert(1, nil)
+				tree.Insert(3, nil)
+				tree.root.right.setParent(tree.root.left)
+				return tree
+			},
+			key:  3,
+			path: "R",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.name == "parent" && !hasParent {
+				t.Skip("nodes have no parent without the gtree_parent tag")
+			}
+			err := tt.tree().Validate()
+
+			e, ok := err.(*InvariantError)
+			if !ok {
+				t.Fatalf("want an *InvariantError, got '%v'", err)
+			}
+			if e.Key != tt.key || e.Path != tt.path {
+				t.Errorf("want key %v at '%v', got '%v'", tt.key, tt.path, e)
+			}
+		})
+	}
+}
+
+func TestValidate_Count(t *testing.T) {
+	tree := New()
+	tree.Insert(1, nil)
+	tree.count = 2
+
+	if err := tree.Validate(); err == nil {
+		t.Errorf("got no error for a wrong count")
+	}
+}
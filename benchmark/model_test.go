@@ -0,0 +1,18 @@
+package benchmark
+
+import (
+	"testing"
+
+	"github.com/masa-suzu/gtree/internal/registry"
+	"github.com/masa-suzu/gtree/modeltest"
+)
+
+// TestModel runs the model-based harness against every registered tree.
+func TestModel(t *testing.T) {
+	for _, impl := range registry.All {
+		impl := impl
+		t.Run(impl.Name, func(t *testing.T) {
+			modeltest.Run(t, func() modeltest.KVS { return impl.New() }, nil)
+		})
+	}
+}
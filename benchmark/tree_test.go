@@ -1,141 +1,320 @@
 package benchmark
 
 import (
+	"fmt"
+	"math/rand"
 	"testing"
 
 	"github.com/masa-suzu/gtree/avl"
+	"github.com/masa-suzu/gtree/internal/registry"
 	"github.com/masa-suzu/gtree/llrb"
 )
 
-type kvs interface {
-	Search(key int) (interface{}, error)
-	Insert(key int, value interface{})
-	Delete(key int)
-	Count() int
+type kvs = registry.KVS
+
+// sizes are num of keys of the Ascending and Descending benchmarks.
+var sizes = []int{10000, 100000, 200000, 400000}
+
+func Benchmark_Ascending(b *testing.B) {
+	for _, n := range sizes {
+		keys := make([]int, n)
+		for i := range keys {
+			keys[i] = i + 1
+		}
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			eachTree(b, func(b *testing.B, newTree func() kvs) {
+				insertSearchDelete(b, newTree, keys, keys, keys)
+			})
+		})
+	}
 }
 
-func Benchmark_Ascending_10000_avl(b *testing.B) {
-	tree := avl.New()
-	ascending(b, tree, 10000)
+func Benchmark_Descending(b *testing.B) {
+	for _, n := range sizes {
+		keys := make([]int, n)
+		for i := range keys {
+			keys[i] = n - i
+		}
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			eachTree(b, func(b *testing.B, newTree func() kvs) {
+				insertSearchDelete(b, newTree, keys, keys, keys)
+			})
+		})
+	}
 }
 
-func Benchmark_Descending_10000_avl(b *testing.B) {
-	tree := avl.New()
-	descending(b, tree, 10000)
-}
+func Benchmark_Random_100000(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	inserted, searched, deleted := r.Perm(100000), r.Perm(100000), r.Perm(100000)
 
-func Benchmark_Ascending_100000_avl(b *testing.B) {
-	tree := avl.New()
-	ascending(b, tree, 100000)
+	eachTree(b, func(b *testing.B, newTree func() kvs) {
+		insertSearchDelete(b, newTree, inserted, searched, deleted)
+	})
 }
 
-func Benchmark_Descending_100000_avl(b *testing.B) {
-	tree := avl.New()
-	descending(b, tree, 100000)
-}
+// Benchmark_Sparse_100000 draws distinct keys from the 32-bit range, while
+// Random uses the dense range [0, n).
+func Benchmark_Sparse_100000(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	seen := map[int]bool{}
+	keys := make([]int, 0, 100000)
+	for len(keys) < 100000 {
+		k := int(r.Uint32())
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
 
-func Benchmark_Ascending_200000_avl(b *testing.B) {
-	tree := avl.New()
-	ascending(b, tree, 200000)
+	eachTree(b, func(b *testing.B, newTree func() kvs) {
+		insertSearchDelete(b, newTree, keys, keys, keys)
+	})
 }
 
-func Benchmark_Descending_200000_avl(b *testing.B) {
-	tree := avl.New()
-	descending(b, tree, 200000)
+func Benchmark_Search_100000(b *testing.B) {
+	eachTree(b, func(b *testing.B, newTree func() kvs) {
+		tree := newTree()
+		fill(tree, 100000)
+		searchAll(b, tree, 100000)
+	})
+	b.Run("avl-frozen", func(b *testing.B) {
+		tree := avl.New()
+		fill(tree, 100000)
+		searchAll(b, tree.Freeze(), 100000)
+	})
 }
 
-func Benchmark_Ascending_400000_avl(b *testing.B) {
-	tree := avl.New()
-	ascending(b, tree, 400000)
-}
-func Benchmark_Descending_400000_avl(b *testing.B) {
-	tree := avl.New()
-	descending(b, tree, 400000)
+// Benchmark_Zipf_100000 and Benchmark_HotKey_100000 skew searches to a few
+// keys, which a splay tree moves near the root.
+func Benchmark_Zipf_100000(b *testing.B) {
+	eachTree(b, func(b *testing.B, newTree func() kvs) {
+		zipf(b, newTree(), 100000)
+	})
 }
 
-func Benchmark_Ascending_1000_llrb(b *testing.B) {
-	tree := llrb.New()
-	ascending(b, tree, 1000)
+func Benchmark_HotKey_100000(b *testing.B) {
+	eachTree(b, func(b *testing.B, newTree func() kvs) {
+		hotKey(b, newTree(), 100000)
+	})
 }
 
-func Benchmark_Descending_1000_llrb(b *testing.B) {
-	tree := llrb.New()
-	descending(b, tree, 1000)
+// Benchmark_DeleteHeavy_100000 replaces keys under churn, where a WAVL tree
+// rotates at most twice per delete.
+func Benchmark_DeleteHeavy_100000(b *testing.B) {
+	eachTree(b, func(b *testing.B, newTree func() kvs) {
+		deleteHeavy(b, newTree(), 100000)
+	})
 }
 
-func Benchmark_Ascending_10000_llrb(b *testing.B) {
-	tree := llrb.New()
-	ascending(b, tree, 10000)
+// Benchmark_Random_100000_int runs Random on the IntTree of avl and llrb,
+// which do not fit kvs.
+func Benchmark_Random_100000_int(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	inserted, searched, deleted := r.Perm(100000), r.Perm(100000), r.Perm(100000)
+
+	for _, tt := range []struct {
+		name    string
+		newTree func() intKVs
+	}{
+		{name: "avl", newTree: func() intKVs { return avl.NewInt() }},
+		{name: "llrb", newTree: func() intKVs { return llrb.NewInt() }},
+	} {
+		tt := tt
+		b.Run(tt.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tree := tt.newTree()
+				for _, k := range inserted {
+					tree.Insert(k, k)
+				}
+				for _, k := range searched {
+					_, _ = tree.Search(k)
+				}
+				for _, k := range deleted {
+					tree.Delete(k)
+				}
+				if tree.Count() != 0 {
+					b.Errorf("num of nodes must be 0, got %v", tree.Count())
+				}
+			}
+		})
+	}
 }
 
-func Benchmark_Descending_10000_llrb(b *testing.B) {
-	tree := llrb.New()
-	descending(b, tree, 10000)
+// Benchmark_Iterate_100000 compares the cursors of avl and llrb with
+// Ascend and Walk.
+func Benchmark_Iterate_100000(b *testing.B) {
+	a := avl.New()
+	fill(a, 100000)
+	l := llrb.New()
+	fill(l, 100000)
+
+	for _, tt := range []struct {
+		name    string
+		iterate func() int
+	}{
+		{name: "avl-cursor", iterate: func() int {
+			n := 0
+			for c := a.First(); c.Valid(); c.Next() {
+				n++
+			}
+			return n
+		}},
+		{name: "avl-ascend", iterate: func() int {
+			n := 0
+			a.Ascend(func(int, interface{}) bool {
+				n++
+				return true
+			})
+			return n
+		}},
+		{name: "llrb-cursor", iterate: func() int {
+			n := 0
+			for c := l.First(); c.Valid(); c.Next() {
+				n++
+			}
+			return n
+		}},
+		{name: "llrb-walk", iterate: func() int {
+			n := 0
+			for range l.Walk() {
+				n++
+			}
+			return n
+		}},
+	} {
+		tt := tt
+		b.Run(tt.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if n := tt.iterate(); n != 100000 {
+					b.Fatalf("want %v entries, got %v", 100000, n)
+				}
+			}
+		})
+	}
 }
 
-func Benchmark_Ascending_100000_llrb(b *testing.B) {
-	tree := llrb.New()
-	ascending(b, tree, 100000)
+// eachTree runs bench on every registered tree.
+func eachTree(b *testing.B, bench func(b *testing.B, newTree func() kvs)) {
+	for _, impl := range registry.All {
+		impl := impl
+		b.Run(impl.Name, func(b *testing.B) {
+			bench(b, impl.New)
+		})
+	}
 }
 
-func Benchmark_Descending_100000_llrb(b *testing.B) {
-	tree := llrb.New()
-	descending(b, tree, 100000)
+// insertSearchDelete inserts, searches and deletes keys in given orders on
+// a new tree in each of b.N iterations, reporting allocations.
+func insertSearchDelete(b *testing.B, newTree func() kvs, inserted, searched, deleted []int) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tree := newTree()
+		for _, k := range inserted {
+			tree.Insert(k, k)
+		}
+		assertNumOfTree(b, tree, len(inserted))
+
+		for _, k := range searched {
+			_, _ = tree.Search(k)
+		}
+		for _, k := range deleted {
+			tree.Delete(k)
+		}
+		assertNumOfTree(b, tree, 0)
+	}
 }
 
-func Benchmark_Ascending_200000_llrb(b *testing.B) {
-	tree := llrb.New()
-	ascending(b, tree, 200000)
+// searchAll searches keys in [0, n) in a shuffled order b.N times.
+func searchAll(b *testing.B, tree interface {
+	Search(key int) (interface{}, error)
+}, n int) {
+	keys := rand.New(rand.NewSource(2)).Perm(n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, k := range keys {
+			if _, err := tree.Search(k); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
 }
 
-func Benchmark_Descending_200000_llrb(b *testing.B) {
-	tree := llrb.New()
-	descending(b, tree, 200000)
+// fill inserts n random keys in [0, n) without duplicates.
+func fill(tree kvs, n int) {
+	for _, k := range rand.New(rand.NewSource(1)).Perm(n) {
+		tree.Insert(k, k)
+	}
 }
 
-func Benchmark_Ascending_400000_llrb(b *testing.B) {
-	tree := llrb.New()
-	ascending(b, tree, 400000)
+// intKVs is a tree mapping int keys to int values.
+type intKVs interface {
+	Search(key int) (int, bool)
+	Insert(key, value int)
+	Delete(key int)
+	Count() int
 }
 
-func ascending(b *testing.B, tree kvs, n int) {
-	for i := n; i > 0; i-- {
-		tree.Insert(i, i)
-	}
-
-	assertNumOfTree(b, tree, n)
-
-	for i := n; i > 0; i-- {
-		_, _ = tree.Search(i)
+// deleteHeavy keeps n keys in the tree and replaces one of them by a new
+// key in every iteration, like a session table under churn.
+func deleteHeavy(b *testing.B, tree kvs, n int) {
+	r := rand.New(rand.NewSource(1))
+	keys := r.Perm(n)
+	for _, k := range keys {
+		tree.Insert(k, k)
 	}
 
-	for i := n; i > 0; i-- {
-		tree.Delete(i)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		j := r.Intn(n)
+		tree.Delete(keys[j])
+		keys[j] = n + i
+		tree.Insert(keys[j], i)
 	}
-
 	b.StopTimer()
 
-	assertNumOfTree(b, tree, 0)
+	assertNumOfTree(b, tree, n)
 }
 
-func descending(b *testing.B, tree kvs, n int) {
-	for i := n; i > 0; i-- {
-		tree.Insert(i, i)
+// zipf searches keys whose ranks follow a Zipf distribution.
+// Ranks are mapped to shuffled keys, so hot keys are not adjacent.
+func zipf(b *testing.B, tree kvs, n int) {
+	r := rand.New(rand.NewSource(1))
+	keys := r.Perm(n)
+	for _, k := range keys {
+		tree.Insert(k, k)
 	}
+	z := rand.NewZipf(r, 1.1, 1, uint64(n-1))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = tree.Search(keys[z.Uint64()])
+	}
+	b.StopTimer()
 
 	assertNumOfTree(b, tree, n)
+}
 
-	for i := n; i > 0; i-- {
-		_, _ = tree.Search(i)
+// hotKey sends 90% of searches to 1% of keys.
+func hotKey(b *testing.B, tree kvs, n int) {
+	r := rand.New(rand.NewSource(1))
+	keys := r.Perm(n)
+	for _, k := range keys {
+		tree.Insert(k, k)
 	}
-
-	for i := n; i > 0; i-- {
-		tree.Delete(i)
+	hot := keys[:n/100]
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if r.Intn(10) == 0 {
+			_, _ = tree.Search(keys[r.Intn(n)])
+		} else {
+			_, _ = tree.Search(hot[r.Intn(len(hot))])
+		}
 	}
-
 	b.StopTimer()
 
-	assertNumOfTree(b, tree, 0)
+	assertNumOfTree(b, tree, n)
 }
 
 func assertNumOfTree(b *testing.B, tree kvs, want int) {
@@ -0,0 +1,37 @@
+package btree
+
+// Underfull returns num of nodes other than the root which are less than
+// a quarter full.
+func (t *Tree) Underfull() (int, error) {
+	return t.underfull(t.root, true)
+}
+
+func (t *Tree) underfull(id uint32, root bool) (int, error) {
+	n, err := t.pool.get(id)
+	if err != nil {
+		return 0, err
+	}
+
+	c := 0
+	if !root && n.size() < t.pageSize/4 {
+		c++
+	}
+	for _, id := range n.children {
+		u, err := t.underfull(id, false)
+		if err != nil {
+			return 0, err
+		}
+		c += u
+	}
+	return c, nil
+}
+
+// Cached returns num of pages in the buffer pool.
+func (t *Tree) Cached() int {
+	return t.pool.lru.Len()
+}
+
+// Free returns the head of the list of freed pages.
+func (t *Tree) Free() uint32 {
+	return t.free
+}
@@ -0,0 +1,161 @@
+package btree
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	pageLeaf     byte = 1
+	pageInternal byte = 2
+	pageFree     byte = 3
+
+	// headerSize is the size of a page header:
+	// a page type, num of keys and the id of the next leaf.
+	headerSize = 8
+)
+
+var errCorrupt = errors.New("btree: corrupt page")
+
+// node is a decoded page.
+// A leaf holds keys with encoded values and links to the next leaf.
+// An internal node holds len(keys)+1 children, where children[i] holds
+// keys in [keys[i-1], keys[i]).
+type node struct {
+	id       uint32
+	leaf     bool
+	keys     []int
+	values   [][]byte
+	children []uint32
+	next     uint32
+	dirty    bool
+}
+
+// search returns the index of the first key not less than key.
+func (n *node) search(key int) (int, bool) {
+	lo, hi := 0, len(n.keys)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if n.keys[mid] < key {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, lo < len(n.keys) && n.keys[lo] == key
+}
+
+// child returns the index of the child which may hold key.
+func (n *node) child(key int) int {
+	i, found := n.search(key)
+	if found {
+		return i + 1
+	}
+	return i
+}
+
+func entrySize(v []byte) int {
+	return 8 + uvarintLen(uint64(len(v))) + len(v)
+}
+
+func (n *node) size() int {
+	if !n.leaf {
+		return headerSize + 4*len(n.children) + 8*len(n.keys)
+	}
+
+	s := headerSize
+	for _, v := range n.values {
+		s += entrySize(v)
+	}
+	return s
+}
+
+func (n *node) encode(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+
+	b[0] = pageInternal
+	if n.leaf {
+		b[0] = pageLeaf
+	}
+	binary.LittleEndian.PutUint16(b[1:], uint16(len(n.keys)))
+	binary.LittleEndian.PutUint32(b[4:], n.next)
+
+	off := headerSize
+	if n.leaf {
+		for i, k := range n.keys {
+			binary.LittleEndian.PutUint64(b[off:], uint64(k))
+			off += 8
+			off += binary.PutUvarint(b[off:], uint64(len(n.values[i])))
+			off += copy(b[off:], n.values[i])
+		}
+		return
+	}
+
+	for _, c := range n.children {
+		binary.LittleEndian.PutUint32(b[off:], c)
+		off += 4
+	}
+	for _, k := range n.keys {
+		binary.LittleEndian.PutUint64(b[off:], uint64(k))
+		off += 8
+	}
+}
+
+func decode(id uint32, b []byte) (*node, error) {
+	if b[0] != pageLeaf && b[0] != pageInternal {
+		return nil, errCorrupt
+	}
+
+	n := &node{
+		id:   id,
+		leaf: b[0] == pageLeaf,
+		next: binary.LittleEndian.Uint32(b[4:]),
+	}
+	count := int(binary.LittleEndian.Uint16(b[1:]))
+	n.keys = make([]int, count)
+
+	off := headerSize
+	if n.leaf {
+		n.values = make([][]byte, count)
+		for i := range n.keys {
+			if off+8 > len(b) {
+				return nil, errCorrupt
+			}
+			n.keys[i] = int(binary.LittleEndian.Uint64(b[off:]))
+			off += 8
+			l, m := binary.Uvarint(b[off:])
+			if m <= 0 || uint64(len(b)-off-m) < l {
+				return nil, errCorrupt
+			}
+			off += m
+			n.values[i] = append([]byte{}, b[off:off+int(l)]...)
+			off += int(l)
+		}
+		return n, nil
+	}
+
+	if headerSize+4*(count+1)+8*count > len(b) {
+		return nil, errCorrupt
+	}
+	n.children = make([]uint32, count+1)
+	for i := range n.children {
+		n.children[i] = binary.LittleEndian.Uint32(b[off:])
+		off += 4
+	}
+	for i := range n.keys {
+		n.keys[i] = int(binary.LittleEndian.Uint64(b[off:]))
+		off += 8
+	}
+	return n, nil
+}
+
+func uvarintLen(x uint64) int {
+	n := 1
+	for x >= 0x80 {
+		x >>= 7
+		n++
+	}
+	return n
+}
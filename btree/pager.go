@@ -0,0 +1,153 @@
+package btree
+
+import (
+	"container/list"
+	"os"
+)
+
+// pager reads and writes fixed-size pages.
+type pager interface {
+	read(id uint32, b []byte) error
+	write(id uint32, b []byte) error
+	sync() error
+	close() error
+}
+
+// filePager stores pages in a local file.
+type filePager struct {
+	f        *os.File
+	pageSize int
+}
+
+func (p *filePager) read(id uint32, b []byte) error {
+	_, err := p.f.ReadAt(b, int64(id)*int64(p.pageSize))
+	return err
+}
+
+func (p *filePager) write(id uint32, b []byte) error {
+	_, err := p.f.WriteAt(b, int64(id)*int64(p.pageSize))
+	return err
+}
+
+func (p *filePager) sync() error {
+	return p.f.Sync()
+}
+
+func (p *filePager) close() error {
+	return p.f.Close()
+}
+
+// memPager stores pages in memory.
+type memPager struct {
+	pages [][]byte
+}
+
+func (p *memPager) read(id uint32, b []byte) error {
+	copy(b, p.pages[id])
+	return nil
+}
+
+func (p *memPager) write(id uint32, b []byte) error {
+	for int(id) >= len(p.pages) {
+		p.pages = append(p.pages, nil)
+	}
+	p.pages[id] = append(p.pages[id][:0], b...)
+	return nil
+}
+
+func (p *memPager) sync() error {
+	return nil
+}
+
+func (p *memPager) close() error {
+	p.pages = nil
+	return nil
+}
+
+// pool is a buffer pool caching decoded pages with LRU eviction.
+// Pages are only evicted by shrink, which Tree calls between operations,
+// so that the nodes on the path of a running operation stay cached.
+type pool struct {
+	pager    pager
+	pageSize int
+	capacity int
+	lru      *list.List // of *node, most recently used first
+	nodes    map[uint32]*list.Element
+	buf      []byte
+}
+
+func newPool(p pager, pageSize, capacity int) *pool {
+	return &pool{
+		pager:    p,
+		pageSize: pageSize,
+		capacity: capacity,
+		lru:      list.New(),
+		nodes:    map[uint32]*list.Element{},
+		buf:      make([]byte, pageSize),
+	}
+}
+
+func (p *pool) get(id uint32) (*node, error) {
+	if e, ok := p.nodes[id]; ok {
+		p.lru.MoveToFront(e)
+		return e.Value.(*node), nil
+	}
+
+	if err := p.pager.read(id, p.buf); err != nil {
+		return nil, err
+	}
+	n, err := decode(id, p.buf)
+	if err != nil {
+		return nil, err
+	}
+	p.nodes[id] = p.lru.PushFront(n)
+	return n, nil
+}
+
+// add caches a node created in memory.
+func (p *pool) add(n *node) {
+	n.dirty = true
+	p.nodes[n.id] = p.lru.PushFront(n)
+}
+
+// drop forgets a node whose page is freed.
+func (p *pool) drop(id uint32) {
+	if e, ok := p.nodes[id]; ok {
+		p.lru.Remove(e)
+		delete(p.nodes, id)
+	}
+}
+
+func (p *pool) shrink() error {
+	for p.lru.Len() > p.capacity {
+		e := p.lru.Back()
+		n := e.Value.(*node)
+		if err := p.writeBack(n); err != nil {
+			return err
+		}
+		p.lru.Remove(e)
+		delete(p.nodes, n.id)
+	}
+	return nil
+}
+
+func (p *pool) flush() error {
+	for e := p.lru.Front(); e != nil; e = e.Next() {
+		if err := p.writeBack(e.Value.(*node)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *pool) writeBack(n *node) error {
+	if !n.dirty {
+		return nil
+	}
+	n.encode(p.buf)
+	if err := p.pager.write(n.id, p.buf); err != nil {
+		return err
+	}
+	n.dirty = false
+	return nil
+}
@@ -0,0 +1,590 @@
+/*
+	Package btree provides an implementation of B+tree over fixed-size pages.
+
+	Pages are stored in a local file or in memory and cached by a buffer pool
+	with LRU eviction. Leaves are linked in ascending order of keys, so range
+	scans do not go back to internal nodes.
+
+	Insert and Delete do not return errors so that Tree has the same methods
+	as avl.Tree and llrb.Tree. The first I/O or encoding error is kept and
+	reported by Err, Sync and Close, and later mutations are ignored.
+
+	The file is consistent only when Sync or Close returns. Pages are
+	updated in place, and evicted or freed pages are written before the
+	meta page, so a crash between syncs can leave a file which cannot be
+	opened or holds a mix of old and new pages.
+*/
+package btree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/masa-suzu/gtree/internal/codec"
+)
+
+const (
+	metaMagic = 0x67747265652b2b31
+	metaPage  = 0
+	minPage   = 64
+	// maxPage keeps num of keys in a page within the uint16 of its header.
+	maxPage = 64 << 10
+)
+
+var (
+	// ErrValueTooLarge is kept when a value does not fit in a quarter of a page.
+	ErrValueTooLarge = errors.New("btree: value too large")
+	// ErrClosed is returned by operations on a closed Tree.
+	ErrClosed = errors.New("btree: tree is closed")
+)
+
+// Options configures a Tree.
+type Options struct {
+	// PageSize is the size of a page in bytes.
+	// It is ignored when an existing file is opened.
+	PageSize int
+	// CacheSize is the num of pages cached by the buffer pool.
+	CacheSize int
+}
+
+// DefaultOptions is used when Open is called with nil options.
+var DefaultOptions = Options{
+	PageSize:  4096,
+	CacheSize: 1024,
+}
+
+// Tree implements a B+tree.
+type Tree struct {
+	pool     *pool
+	pageSize int
+	root     uint32
+	count    int
+	pages    uint32 // num of pages, including the meta page
+	free     uint32 // head of the list of freed pages
+	closed   bool
+	err      error
+}
+
+// New returns a reference to an empty Tree kept in memory.
+func New() *Tree {
+	t, _ := Open("", nil)
+	return t
+}
+
+// Open opens the Tree stored in the file at path, creating it if needed.
+// If path is empty, the Tree is kept in memory.
+func Open(path string, opts *Options) (*Tree, error) {
+	if opts == nil {
+		opts = &DefaultOptions
+	}
+	var p pager = &memPager{}
+	size := int64(0)
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		p = &filePager{f: f, pageSize: opts.PageSize}
+		size = fi.Size()
+	}
+
+	t := &Tree{pageSize: opts.PageSize}
+	if size == 0 {
+		if opts.PageSize < minPage || opts.PageSize > maxPage {
+			p.close()
+			return nil, fmt.Errorf("btree: page size must be in [%v, %v], got %v", minPage, maxPage, opts.PageSize)
+		}
+		t.pages = 1
+		t.pool = newPool(p, t.pageSize, opts.CacheSize)
+		root, err := t.allocate(true)
+		if err != nil {
+			p.close()
+			return nil, err
+		}
+		t.root = root.id
+		return t, nil
+	}
+
+	if err := t.readMeta(p.(*filePager)); err != nil {
+		p.close()
+		return nil, fmt.Errorf("btree: %v: %v", path, err)
+	}
+	t.pool = newPool(p, t.pageSize, opts.CacheSize)
+	return t, nil
+}
+
+func (t *Tree) readMeta(p *filePager) error {
+	b := make([]byte, minPage)
+	if _, err := p.f.ReadAt(b, 0); err != nil {
+		return err
+	}
+	if binary.LittleEndian.Uint64(b) != metaMagic {
+		return errCorrupt
+	}
+
+	t.pageSize = int(binary.LittleEndian.Uint32(b[8:]))
+	if t.pageSize < minPage || t.pageSize > maxPage {
+		return errCorrupt
+	}
+	t.root = binary.LittleEndian.Uint32(b[12:])
+	t.count = int(binary.LittleEndian.Uint64(b[16:]))
+	t.pages = binary.LittleEndian.Uint32(b[24:])
+	t.free = binary.LittleEndian.Uint32(b[28:])
+	p.pageSize = t.pageSize
+	return nil
+}
+
+func (t *Tree) writeMeta() error {
+	b := make([]byte, t.pageSize)
+	binary.LittleEndian.PutUint64(b, metaMagic)
+	binary.LittleEndian.PutUint32(b[8:], uint32(t.pageSize))
+	binary.LittleEndian.PutUint32(b[12:], t.root)
+	binary.LittleEndian.PutUint64(b[16:], uint64(t.count))
+	binary.LittleEndian.PutUint32(b[24:], t.pages)
+	binary.LittleEndian.PutUint32(b[28:], t.free)
+	return t.pool.pager.write(metaPage, b)
+}
+
+// Count returns num of keys.
+func (t *Tree) Count() int {
+	return t.count
+}
+
+// Err returns the first error met by Insert or Delete.
+func (t *Tree) Err() error {
+	return t.err
+}
+
+// Search returns a value associated with a given key.
+// If no value is found by the key, returns nil with an error.
+func (t *Tree) Search(key int) (interface{}, error) {
+	if t.closed {
+		return nil, ErrClosed
+	}
+
+	n, err := t.leaf(key)
+	if err == nil {
+		err = t.pool.shrink()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	i, found := n.search(key)
+	if !found {
+		return nil, fmt.Errorf("found no value by key '%v'", key)
+	}
+	v, _, err := codec.DecodeValue(n.values[i])
+	return v, err
+}
+
+// leaf returns the leaf which may hold key.
+func (t *Tree) leaf(key int) (*node, error) {
+	n, err := t.pool.get(t.root)
+	for err == nil && !n.leaf {
+		n, err = t.pool.get(n.children[n.child(key)])
+	}
+	return n, err
+}
+
+// Insert a value with a given key.
+// If the same key has already inserted, the new value overrides old one.
+func (t *Tree) Insert(key int, value interface{}) {
+	if t.err != nil || t.closed {
+		return
+	}
+
+	v, err := codec.AppendValue(nil, value)
+	if err == nil && entrySize(v) > (t.pageSize-headerSize)/4 {
+		err = ErrValueTooLarge
+	}
+	if err == nil {
+		err = t.insert(key, v)
+	}
+	if err == nil {
+		err = t.pool.shrink()
+	}
+	t.err = err
+}
+
+func (t *Tree) insert(key int, v []byte) error {
+	root, err := t.pool.get(t.root)
+	if err != nil {
+		return err
+	}
+
+	sep, right, err := t.insertAt(root, key, v)
+	if err != nil || right == nil {
+		return err
+	}
+
+	n, err := t.allocate(false)
+	if err != nil {
+		return err
+	}
+	n.keys = []int{sep}
+	n.children = []uint32{root.id, right.id}
+	t.root = n.id
+	return nil
+}
+
+// insertAt inserts key into the subtree of n.
+// If n is split, it returns the new right sibling and its separator key.
+func (t *Tree) insertAt(n *node, key int, v []byte) (int, *node, error) {
+	if n.leaf {
+		i, found := n.search(key)
+		if found {
+			n.values[i] = v
+		} else {
+			n.keys = append(n.keys, 0)
+			copy(n.keys[i+1:], n.keys[i:])
+			n.keys[i] = key
+			n.values = append(n.values, nil)
+			copy(n.values[i+1:], n.values[i:])
+			n.values[i] = v
+			t.count++
+		}
+		n.dirty = true
+
+		if n.size() <= t.pageSize {
+			return 0, nil, nil
+		}
+		return t.splitLeaf(n)
+	}
+
+	i := n.child(key)
+	c, err := t.pool.get(n.children[i])
+	if err != nil {
+		return 0, nil, err
+	}
+	sep, right, err := t.insertAt(c, key, v)
+	if err != nil || right == nil {
+		return 0, nil, err
+	}
+
+	n.keys = append(n.keys, 0)
+	copy(n.keys[i+1:], n.keys[i:])
+	n.keys[i] = sep
+	n.children = append(n.children, 0)
+	copy(n.children[i+2:], n.children[i+1:])
+	n.children[i+1] = right.id
+	n.dirty = true
+
+	if n.size() <= t.pageSize {
+		return 0, nil, nil
+	}
+	return t.splitInternal(n)
+}
+
+func (t *Tree) splitLeaf(n *node) (int, *node, error) {
+	// move the upper half of bytes to the right sibling
+	mid, s := 0, headerSize
+	for mid < len(n.keys)-1 && s < n.size()/2 {
+		s += entrySize(n.values[mid])
+		mid++
+	}
+
+	r, err := t.allocate(true)
+	if err != nil {
+		return 0, nil, err
+	}
+	r.keys = append([]int{}, n.keys[mid:]...)
+	r.values = append([][]byte{}, n.values[mid:]...)
+	r.next = n.next
+	n.keys = n.keys[:mid:mid]
+	n.values = n.values[:mid:mid]
+	n.next = r.id
+	return r.keys[0], r, nil
+}
+
+func (t *Tree) splitInternal(n *node) (int, *node, error) {
+	mid := len(n.keys) / 2
+	sep := n.keys[mid]
+
+	r, err := t.allocate(false)
+	if err != nil {
+		return 0, nil, err
+	}
+	r.keys = append([]int{}, n.keys[mid+1:]...)
+	r.children = append([]uint32{}, n.children[mid+1:]...)
+	n.keys = n.keys[:mid:mid]
+	n.children = n.children[: mid+1 : mid+1]
+	return sep, r, nil
+}
+
+// Delete remove a node by a given key.
+// If the key does not found, do nothing.
+func (t *Tree) Delete(key int) {
+	if t.err != nil || t.closed {
+		return
+	}
+
+	err := t.delete(key)
+	if err == nil {
+		err = t.pool.shrink()
+	}
+	t.err = err
+}
+
+func (t *Tree) delete(key int) error {
+	root, err := t.pool.get(t.root)
+	if err != nil {
+		return err
+	}
+	if _, err := t.deleteAt(root, key); err != nil {
+		return err
+	}
+
+	if !root.leaf && len(root.keys) == 0 {
+		t.root = root.children[0]
+		return t.release(root)
+	}
+	return nil
+}
+
+// deleteAt deletes key from the subtree of n and reports whether n is
+// less than a quarter full.
+func (t *Tree) deleteAt(n *node, key int) (bool, error) {
+	if n.leaf {
+		i, found := n.search(key)
+		if !found {
+			return false, nil
+		}
+		n.keys = append(n.keys[:i], n.keys[i+1:]...)
+		n.values = append(n.values[:i], n.values[i+1:]...)
+		n.dirty = true
+		t.count--
+		return n.size() < t.pageSize/4, nil
+	}
+
+	i := n.child(key)
+	c, err := t.pool.get(n.children[i])
+	if err != nil {
+		return false, err
+	}
+	under, err := t.deleteAt(c, key)
+	if err != nil || !under || len(n.children) < 2 {
+		return false, err
+	}
+
+	// merge the child with its right sibling, or the left one for the last
+	// child, or move keys between them if they do not fit in a page
+	if i == len(n.children)-1 {
+		i--
+	}
+	left, err := t.pool.get(n.children[i])
+	if err != nil {
+		return false, err
+	}
+	right, err := t.pool.get(n.children[i+1])
+	if err != nil {
+		return false, err
+	}
+	if err := t.merge(n, i, left, right); err != nil {
+		return false, err
+	}
+	return n.size() < t.pageSize/4, nil
+}
+
+// merge moves every key of right, the child of parent at i+1, into left
+// if they fit in a page, and otherwise redistributes the keys evenly.
+func (t *Tree) merge(parent *node, i int, left, right *node) error {
+	if left.leaf {
+		if left.size()+right.size()-headerSize > t.pageSize {
+			redistributeLeaves(parent, i, left, right)
+			return nil
+		}
+		left.keys = append(left.keys, right.keys...)
+		left.values = append(left.values, right.values...)
+		left.next = right.next
+	} else {
+		if left.size()+right.size()-headerSize+8 > t.pageSize {
+			redistributeInternals(parent, i, left, right)
+			return nil
+		}
+		left.keys = append(append(left.keys, parent.keys[i]), right.keys...)
+		left.children = append(left.children, right.children...)
+	}
+	left.dirty = true
+
+	parent.keys = append(parent.keys[:i], parent.keys[i+1:]...)
+	parent.children = append(parent.children[:i+1], parent.children[i+2:]...)
+	parent.dirty = true
+	return t.release(right)
+}
+
+// redistributeLeaves splits the entries of two sibling leaves into halves
+// of bytes, as splitLeaf does.
+func redistributeLeaves(parent *node, i int, left, right *node) {
+	keys := append(append([]int{}, left.keys...), right.keys...)
+	values := append(append([][]byte{}, left.values...), right.values...)
+
+	total := left.size() + right.size() - headerSize
+	mid, s := 0, headerSize
+	for mid < len(keys)-1 && s < total/2 {
+		s += entrySize(values[mid])
+		mid++
+	}
+
+	left.keys, right.keys = keys[:mid:mid], keys[mid:]
+	left.values, right.values = values[:mid:mid], values[mid:]
+	parent.keys[i] = right.keys[0]
+	left.dirty, right.dirty, parent.dirty = true, true, true
+}
+
+// redistributeInternals splits the keys of two sibling internal nodes and
+// their separator into halves, rotating the middle key into parent.
+func redistributeInternals(parent *node, i int, left, right *node) {
+	keys := append(append(append([]int{}, left.keys...), parent.keys[i]), right.keys...)
+	children := append(append([]uint32{}, left.children...), right.children...)
+
+	mid := len(keys) / 2
+	parent.keys[i] = keys[mid]
+	left.keys, right.keys = keys[:mid:mid], keys[mid+1:]
+	left.children, right.children = children[:mid+1:mid+1], children[mid+1:]
+	left.dirty, right.dirty, parent.dirty = true, true, true
+}
+
+// allocate returns a new node on a freed page or a page at the end.
+// It fails if the head of the list of freed pages cannot be read, keeping
+// the list instead of leaking its pages.
+func (t *Tree) allocate(leaf bool) (*node, error) {
+	id := t.pages
+	if t.free != 0 {
+		b := make([]byte, t.pageSize)
+		if err := t.pool.pager.read(t.free, b); err != nil {
+			return nil, err
+		}
+		if b[0] != pageFree {
+			return nil, errCorrupt
+		}
+		id = t.free
+		t.free = binary.LittleEndian.Uint32(b[4:])
+	}
+	if id == t.pages {
+		t.pages++
+	}
+
+	n := &node{id: id, leaf: leaf}
+	t.pool.add(n)
+	return n, nil
+}
+
+// release adds the page of n to the list of freed pages.
+func (t *Tree) release(n *node) error {
+	t.pool.drop(n.id)
+
+	b := make([]byte, t.pageSize)
+	b[0] = pageFree
+	binary.LittleEndian.PutUint32(b[4:], t.free)
+	if err := t.pool.pager.write(n.id, b); err != nil {
+		return err
+	}
+	t.free = n.id
+	return nil
+}
+
+// Ascend calls f for each key-value pair in ascending order of keys.
+// If f returns false, Ascend stops the iteration.
+func (t *Tree) Ascend(f func(key int, value interface{}) bool) error {
+	if t.closed {
+		return ErrClosed
+	}
+
+	n, err := t.pool.get(t.root)
+	for err == nil && !n.leaf {
+		n, err = t.pool.get(n.children[0])
+	}
+	if err != nil {
+		return err
+	}
+	return t.scan(n, 0, nil, f)
+}
+
+// AscendRange calls f for each key-value pair with a key in [from, to)
+// in ascending order of keys. If f returns false, AscendRange stops
+// the iteration.
+func (t *Tree) AscendRange(from, to int, f func(key int, value interface{}) bool) error {
+	if t.closed {
+		return ErrClosed
+	}
+
+	n, err := t.leaf(from)
+	if err != nil {
+		return err
+	}
+	i, _ := n.search(from)
+	return t.scan(n, i, &to, f)
+}
+
+// scan walks the linked leaves from the i-th key of n.
+// f must not modify the tree.
+func (t *Tree) scan(n *node, i int, to *int, f func(int, interface{}) bool) error {
+	for {
+		for ; i < len(n.keys); i++ {
+			if to != nil && n.keys[i] >= *to {
+				return t.pool.shrink()
+			}
+			v, _, err := codec.DecodeValue(n.values[i])
+			if err != nil {
+				return err
+			}
+			if !f(n.keys[i], v) {
+				return t.pool.shrink()
+			}
+		}
+		if n.next == 0 {
+			return t.pool.shrink()
+		}
+
+		var err error
+		if n, err = t.pool.get(n.next); err != nil {
+			return err
+		}
+		// evict the leaves already scanned, so that a scan keeps no more
+		// pages than the cache holds
+		if err = t.pool.shrink(); err != nil {
+			return err
+		}
+		i = 0
+	}
+}
+
+// Sync writes every modified page and the meta page to the file.
+// The file is consistent once Sync returns without an error.
+func (t *Tree) Sync() error {
+	if t.closed {
+		return ErrClosed
+	}
+	if t.err != nil {
+		return t.err
+	}
+
+	if err := t.pool.flush(); err != nil {
+		return err
+	}
+	if err := t.writeMeta(); err != nil {
+		return err
+	}
+	return t.pool.pager.sync()
+}
+
+// Close syncs and closes the Tree.
+func (t *Tree) Close() error {
+	err := t.Sync()
+	if err == ErrClosed {
+		return err
+	}
+	t.closed = true
+	if cerr := t.pool.pager.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
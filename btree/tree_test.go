@@ -0,0 +1,589 @@
+package btree_test
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/masa-suzu/gtree/btree"
+	"github.com/masa-suzu/gtree/modeltest"
+)
+
+type kv struct {
+	k int
+	v interface{}
+}
+
+func TestNewTree(t *testing.T) {
+
+	want := 0
+	got := btree.New().Count()
+
+	if want != got {
+		t.Errorf("num of nodes must be %v, got %v", want, got)
+	}
+}
+
+func TestInsert(t *testing.T) {
+
+	tests := []struct {
+		name string
+		want []kv
+	}{
+		{
+			name: "integers",
+			want: []kv{
+				{k: 2, v: 100},
+				{k: 1, v: 200},
+			},
+		},
+		{
+			name: "strings",
+			want: []kv{
+				{k: 1, v: "200"},
+				{k: 2, v: "100"},
+			},
+		},
+		{
+			name: "integers_and_strings",
+			want: []kv{
+				{k: 4, v: 100},
+				{k: 3, v: 200},
+				{k: 1, v: "100"},
+				{k: 2, v: "200"},
+			},
+		},
+	}
+
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := btree.New()
+			for _, kv := range tt.want {
+				tree.Insert(kv.k, kv.v)
+			}
+			assertTree(t, tree, tt.want)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name    string
+		deleted []kv
+		want    []kv
+	}{
+		{
+			name: "ascending",
+			deleted: []kv{
+				{k: 1, v: 200},
+				{k: 2, v: 2400},
+				{k: 3, v: 2040},
+			},
+			want: []kv{
+				{k: 4, v: 100},
+				{k: 5, v: 100},
+			},
+		},
+		{
+			name: "descending",
+			deleted: []kv{
+				{k: 5, v: 200},
+				{k: 4, v: 2400},
+				{k: 3, v: 2040},
+			},
+			want: []kv{
+				{k: 2, v: 100},
+				{k: 1, v: 100},
+			},
+		},
+		{
+			name: "random-ordering",
+			deleted: []kv{
+				{k: 6, v: 200},
+				{k: 10, v: 2400},
+				{k: 1, v: 2040},
+				{k: 9, v: 2040},
+				{k: 8, v: 2040},
+				{k: 2, v: 2040},
+			},
+			want: []kv{
+				{k: 4, v: 100},
+				{k: 11, v: 100},
+			},
+		},
+	}
+
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := btree.New()
+
+			// insert all key-value pairs
+			for _, kv := range tt.deleted {
+				tree.Insert(kv.k, kv.v)
+			}
+
+			for _, kv := range tt.want {
+				tree.Insert(kv.k, kv.v)
+			}
+
+			// delete nodes of tt.delete
+			for _, kv := range tt.deleted {
+				tree.Delete(kv.k)
+			}
+			assertTree(t, tree, tt.want)
+		})
+	}
+}
+
+func assertTree(t *testing.T, tree *btree.Tree, kvs []kv) {
+	if len(kvs) != tree.Count() {
+		t.Errorf("num of nodes must be %v, got %v", len(kvs), tree.Count())
+	}
+
+	for _, kv := range kvs {
+		got, err := tree.Search(kv.k)
+
+		if err != nil {
+			t.Errorf("got an error '%v'", err)
+		}
+		if kv.v != got {
+			t.Errorf("want %v, got %v", kv.v, got)
+		}
+	}
+}
+
+func TestInsert_with_SameKeys(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		kvs    []kv
+		unique int
+		want   interface{}
+	}{
+		{
+			name: "Want_Last_Inserted",
+			kvs: []kv{
+				{k: 1, v: 300},
+				{k: 1, v: 100},
+				{k: 1, v: 200},
+			},
+			unique: 1,
+			want:   200,
+		},
+	}
+
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := btree.New()
+
+			for i := 0; i < len(tt.kvs); i++ {
+				tree.Insert(tt.kvs[i].k, tt.kvs[i].v)
+			}
+
+			if tt.unique != tree.Count() {
+				t.Errorf("num of nodes must be %v, got %v", tt.unique, tree.Count())
+			}
+
+			got, err := tree.Search(tt.kvs[0].k)
+
+			if err != nil {
+				t.Errorf("got an error '%v'", err)
+			}
+			if tt.want != got {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSearch_by_InvalidKey(t *testing.T) {
+
+	in := kv{k: 1, v: 100}
+
+	tree := btree.New()
+	tree.Insert(in.k, in.v)
+
+	got, err := tree.Search(100)
+
+	if err == nil {
+		t.Errorf("got no error, want '%v'", err)
+	}
+
+	if got != nil {
+		t.Errorf("got %v, want %v", got, nil)
+	}
+
+}
+
+func TestAscend(t *testing.T) {
+	tests := []struct {
+		name     string
+		inserted []kv
+		stop     int
+		want     []kv
+	}{
+		{
+			name:     "empty",
+			inserted: []kv{},
+			want:     []kv{},
+		},
+		{
+			name: "random-ordering",
+			inserted: []kv{
+				{k: 6, v: 600},
+				{k: 10, v: nil},
+				{k: 1, v: 100},
+				{k: 9, v: 900},
+			},
+			want: []kv{
+				{k: 1, v: 100},
+				{k: 6, v: 600},
+				{k: 9, v: 900},
+				{k: 10, v: nil},
+			},
+		},
+		{
+			name: "stop",
+			inserted: []kv{
+				{k: 3, v: 300},
+				{k: 2, v: 200},
+				{k: 1, v: 100},
+			},
+			stop: 2,
+			want: []kv{
+				{k: 1, v: 100},
+				{k: 2, v: 200},
+			},
+		},
+	}
+
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := btree.New()
+			for _, kv := range tt.inserted {
+				tree.Insert(kv.k, kv.v)
+			}
+
+			got := []kv{}
+			err := tree.Ascend(func(key int, value interface{}) bool {
+				got = append(got, kv{k: key, v: value})
+				return key != tt.stop
+			})
+			if err != nil {
+				t.Fatalf("got an error '%v'", err)
+			}
+
+			if len(tt.want) != len(got) {
+				t.Fatalf("num of nodes must be %v, got %v", len(tt.want), len(got))
+			}
+
+			for i, want := range tt.want {
+				if want != got[i] {
+					t.Errorf("want %v, got %v", want, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRandom_SmallPages(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "memory"},
+		{name: "file", path: filepath.Join(t.TempDir(), "tree.db")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := btree.Open(tt.path, &btree.Options{PageSize: 128, CacheSize: 4})
+			if err != nil {
+				t.Fatalf("got an error '%v'", err)
+			}
+
+			m := map[int]interface{}{}
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < 5000; i++ {
+				k := r.Intn(500)
+				if r.Intn(2) == 0 {
+					tree.Delete(k)
+					delete(m, k)
+					continue
+				}
+				v := strings.Repeat("v", r.Intn(20))
+				tree.Insert(k, v)
+				m[k] = v
+			}
+			if err := tree.Err(); err != nil {
+				t.Fatalf("got an error '%v'", err)
+			}
+
+			assertTree(t, tree, model(m))
+			assertOrder(t, tree, len(m))
+
+			// delete everything to exercise merges up to the root
+			for k := range m {
+				tree.Delete(k)
+			}
+			assertTree(t, tree, []kv{})
+			tree.Close()
+		})
+	}
+}
+
+// checked reports the first error of a Tree after every command.
+type checked struct {
+	*btree.Tree
+}
+
+func (c checked) Validate() error {
+	return c.Err()
+}
+
+func TestModel_SmallPages(t *testing.T) {
+	modeltest.Run(t, func() modeltest.KVS {
+		tree, err := btree.Open("", &btree.Options{PageSize: 128, CacheSize: 4})
+		if err != nil {
+			t.Fatalf("got an error '%v'", err)
+		}
+		return checked{tree}
+	}, nil)
+}
+
+func TestOpen_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+
+	tree, err := btree.Open(path, &btree.Options{PageSize: 256, CacheSize: 2})
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	m := map[int]interface{}{}
+	for i := 1000; i > 0; i-- {
+		tree.Insert(i, i*100)
+		m[i] = i * 100
+	}
+	for i := 1; i <= 1000; i += 3 {
+		tree.Delete(i)
+		delete(m, i)
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	tree, err = btree.Open(path, nil)
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	defer tree.Close()
+
+	assertTree(t, tree, model(m))
+	assertOrder(t, tree, len(m))
+}
+
+func TestOpen_IgnorePageSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+
+	tree, err := btree.Open(path, &btree.Options{PageSize: 256, CacheSize: 2})
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	tree.Insert(1, 100)
+	if err := tree.Close(); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	// the page size of an existing file is read from the file
+	tree, err = btree.Open(path, &btree.Options{PageSize: 1, CacheSize: 2})
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	defer tree.Close()
+
+	assertTree(t, tree, []kv{{k: 1, v: 100}})
+}
+
+func TestInsert_CorruptFreeList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+
+	tree, _ := btree.Open(path, &btree.Options{PageSize: 128, CacheSize: 4})
+	for k := 0; k < 500; k++ {
+		tree.Insert(k, k)
+	}
+	for k := 0; k < 500; k++ {
+		tree.Delete(k)
+	}
+	free := tree.Free()
+	if free == 0 {
+		t.Fatal("want freed pages")
+	}
+	if err := tree.Close(); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteAt([]byte{0}, int64(free)*128)
+	f.Close()
+
+	tree, err = btree.Open(path, nil)
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	defer tree.Close()
+
+	for k := 0; k < 500 && tree.Err() == nil; k++ {
+		tree.Insert(k, k)
+	}
+	if tree.Err() == nil {
+		t.Error("want an error for the corrupt free list")
+	}
+	if tree.Free() != free {
+		t.Errorf("free list must be kept at %v, got %v", free, tree.Free())
+	}
+}
+
+func TestAscend_EvictScannedLeaves(t *testing.T) {
+	tree, _ := btree.Open("", &btree.Options{PageSize: 128, CacheSize: 4})
+	for k := 0; k < 2000; k++ {
+		tree.Insert(k, k)
+	}
+
+	n := 0
+	err := tree.Ascend(func(key int, value interface{}) bool {
+		if n++; n > 100 && tree.Cached() > 4 {
+			t.Fatalf("want at most 4 cached pages at %v, got %v", key, tree.Cached())
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+}
+
+func TestAscendRange(t *testing.T) {
+	tree, _ := btree.Open("", &btree.Options{PageSize: 64, CacheSize: 8})
+	for i := 0; i < 100; i++ {
+		tree.Insert(i*2, i)
+	}
+
+	got := []int{}
+	err := tree.AscendRange(11, 21, func(key int, value interface{}) bool {
+		got = append(got, key)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	want := []int{12, 14, 16, 18, 20}
+	if len(want) != len(got) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	}
+}
+
+func TestInsert_ValueTooLarge(t *testing.T) {
+	tree, _ := btree.Open("", &btree.Options{PageSize: 64, CacheSize: 8})
+	tree.Insert(1, strings.Repeat("v", 64))
+
+	if tree.Err() != btree.ErrValueTooLarge {
+		t.Errorf("want '%v', got '%v'", btree.ErrValueTooLarge, tree.Err())
+	}
+	if tree.Count() != 0 {
+		t.Errorf("num of nodes must be %v, got %v", 0, tree.Count())
+	}
+}
+
+func TestOpen_InvalidPageSize(t *testing.T) {
+	for _, size := range []int{32, 1 << 20} {
+		if _, err := btree.Open("", &btree.Options{PageSize: size, CacheSize: 8}); err == nil {
+			t.Errorf("got no error for page size %v", size)
+		}
+	}
+}
+
+func TestAscend_Closed(t *testing.T) {
+	tree, _ := btree.Open("", &btree.Options{PageSize: 128, CacheSize: 1})
+	for k := 0; k < 100; k++ {
+		tree.Insert(k, k)
+	}
+	tree.Close()
+
+	f := func(int, interface{}) bool { return true }
+	if err := tree.Ascend(f); err != btree.ErrClosed {
+		t.Errorf("want '%v', got '%v'", btree.ErrClosed, err)
+	}
+	if err := tree.AscendRange(10, 20, f); err != btree.ErrClosed {
+		t.Errorf("want '%v', got '%v'", btree.ErrClosed, err)
+	}
+}
+
+func TestDelete_Redistribute(t *testing.T) {
+	tree, _ := btree.Open("", &btree.Options{PageSize: 128, CacheSize: 4})
+	r := rand.New(rand.NewSource(1))
+	for _, k := range r.Perm(2000) {
+		tree.Insert(k, "")
+	}
+
+	// some pages thinned out have full siblings to borrow from
+	m := map[int]interface{}{}
+	for _, k := range r.Perm(2000) {
+		if k%3 == 0 {
+			m[k] = ""
+		} else {
+			tree.Delete(k)
+		}
+	}
+
+	if n, err := tree.Underfull(); err != nil || n != 0 {
+		t.Errorf("want no underfull pages, got %v, %v", n, err)
+	}
+	assertTree(t, tree, model(m))
+	assertOrder(t, tree, len(m))
+}
+
+func model(m map[int]interface{}) []kv {
+	kvs := []kv{}
+	for k, v := range m {
+		kvs = append(kvs, kv{k: k, v: v})
+	}
+	return kvs
+}
+
+func assertOrder(t *testing.T, tree *btree.Tree, want int) {
+	t.Helper()
+
+	n, prev := 0, -1
+	err := tree.Ascend(func(key int, value interface{}) bool {
+		if key <= prev {
+			t.Errorf("keys must be ascending, got %v after %v", key, prev)
+		}
+		prev = key
+		n++
+		return true
+	})
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	if want != n {
+		t.Errorf("num of nodes must be %v, got %v", want, n)
+	}
+}
@@ -0,0 +1,287 @@
+/*
+	Command gtree-anim generates a self-contained HTML page replaying the
+	structural changes of operations on an avl or llrb tree step by step.
+
+	Usage:
+
+		gtree-anim [-tree avl|llrb] [-title title] [-o page.html] ops
+
+	ops is a list of operations separated by spaces or commas, where "i10"
+	inserts the key 10 with the value 10 and "d10" deletes the key 10:
+
+		gtree-anim -tree llrb -o llrb.html "i10 i20 i30 i40 i50 i25 d20"
+
+	The page draws the whole tree after each operation and the changed
+	subtree after each rotation, flip or other event in between.
+	Use the buttons or the arrow keys to step through them.
+*/
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/masa-suzu/gtree/avl"
+	"github.com/masa-suzu/gtree/internal/htmltree"
+	"github.com/masa-suzu/gtree/llrb"
+)
+
+func main() {
+	kind := flag.String("tree", "llrb", "tree to animate: avl or llrb")
+	title := flag.String("title", "", "title of the page (default: the tree and the ops)")
+	out := flag.String("o", "", "output file (default: stdout)")
+	flag.Parse()
+
+	if err := run(*kind, *title, *out, strings.Join(flag.Args(), " ")); err != nil {
+		fmt.Fprintln(os.Stderr, "gtree-anim:", err)
+		os.Exit(1)
+	}
+}
+
+func run(kind, title, out, ops string) error {
+	parsed, err := parseOps(ops)
+	if err != nil {
+		return err
+	}
+
+	var t animated
+	switch kind {
+	case "avl":
+		t = newAVL()
+	case "llrb":
+		t = newLLRB()
+	default:
+		return fmt.Errorf("unknown tree '%v'", kind)
+	}
+	frames, err := record(t, parsed)
+	if err != nil {
+		return err
+	}
+
+	if title == "" {
+		title = fmt.Sprintf("%v: %v", kind, ops)
+	}
+
+	if out == "" {
+		return writePage(os.Stdout, title, frames)
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := writePage(f, title, frames); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// op is an operation on a tree.
+type op struct {
+	insert bool
+	key    int
+}
+
+func (o op) String() string {
+	if o.insert {
+		return fmt.Sprintf("Insert(%v)", o.key)
+	}
+	return fmt.Sprintf("Delete(%v)", o.key)
+}
+
+func parseOps(s string) ([]op, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '\t' || r == '\n'
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no ops")
+	}
+
+	ops := make([]op, 0, len(fields))
+	for _, f := range fields {
+		if len(f) < 2 || (f[0] != 'i' && f[0] != 'd') {
+			return nil, fmt.Errorf("invalid op '%v', want i<key> or d<key>", f)
+		}
+		key, err := strconv.Atoi(f[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid op '%v': %v", f, err)
+		}
+		ops = append(ops, op{insert: f[0] == 'i', key: key})
+	}
+	return ops, nil
+}
+
+// frame is a step of the animation.
+type frame struct {
+	Caption string
+	Tree    template.HTML
+	Final   bool // the whole tree after an operation
+}
+
+// htmlWriter is implemented by avl.Tree and llrb.Tree.
+type htmlWriter interface {
+	WriteHTML(w io.Writer) error
+}
+
+func render(t htmlWriter) (template.HTML, error) {
+	b := &bytes.Buffer{}
+	if err := t.WriteHTML(b); err != nil {
+		return "", err
+	}
+	// WriteHTML escapes keys and values with html/template.
+	return template.HTML(b.String()), nil
+}
+
+// animated is a tree recording its events, which is adapted from
+// avl.Tree or llrb.Tree.
+type animated interface {
+	htmlWriter
+	Insert(key int, value interface{})
+	Delete(key int)
+	// reset forgets recorded events.
+	reset()
+	// events returns the events recorded since the last reset.
+	events() []event
+}
+
+// event is a recorded event with a copy of the changed subtree.
+type event struct {
+	caption string
+	subtree htmlWriter
+}
+
+type avlTree struct {
+	*avl.Tree
+	r *avl.Recorder
+}
+
+func newAVL() animated {
+	t := avlTree{Tree: avl.New(), r: &avl.Recorder{}}
+	t.SetRecorder(t.r)
+	return t
+}
+
+func (t avlTree) reset() { t.r.Reset() }
+
+func (t avlTree) events() []event {
+	events := make([]event, 0, len(t.r.Events))
+	for _, e := range t.r.Events {
+		events = append(events, event{caption: e.String(), subtree: e.Subtree})
+	}
+	return events
+}
+
+type llrbTree struct {
+	*llrb.Tree
+	r *llrb.Recorder
+}
+
+func newLLRB() animated {
+	t := llrbTree{Tree: llrb.New(), r: &llrb.Recorder{}}
+	t.SetRecorder(t.r)
+	return t
+}
+
+func (t llrbTree) reset() { t.r.Reset() }
+
+func (t llrbTree) events() []event {
+	events := make([]event, 0, len(t.r.Events))
+	for _, e := range t.r.Events {
+		events = append(events, event{caption: e.String(), subtree: e.Subtree})
+	}
+	return events
+}
+
+func record(t animated, ops []op) ([]frame, error) {
+	frames := []frame{}
+	add := func(caption string, t htmlWriter, final bool) error {
+		h, err := render(t)
+		frames = append(frames, frame{Caption: caption, Tree: h, Final: final})
+		return err
+	}
+
+	if err := add("empty tree", t, true); err != nil {
+		return nil, err
+	}
+	for _, o := range ops {
+		t.reset()
+		if o.insert {
+			t.Insert(o.key, o.key)
+		} else {
+			t.Delete(o.key)
+		}
+
+		events := t.events()
+		for i, e := range events {
+			if err := add(fmt.Sprintf("%v: step %v/%v: %v", o, i+1, len(events), e.caption), e.subtree, false); err != nil {
+				return nil, err
+			}
+		}
+		if err := add(fmt.Sprintf("%v: done", o), t, true); err != nil {
+			return nil, err
+		}
+	}
+	return frames, nil
+}
+
+func writePage(w io.Writer, title string, frames []frame) error {
+	return page.Execute(w, struct {
+		Title  string
+		Frames []frame
+	}{title, frames})
+}
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>{{.Title}}</title>
+<style>` + htmltree.CSS + `
+.controls { padding: 10px; font-family: arial, verdana, tahoma; font-size: 13px; }
+.controls button { padding: 2px 10px; }
+.frame { padding: 10px; }
+.frame.step .tree li span { border-style: dashed; }
+</style>
+</head>
+<body>
+<div class="controls">
+<button id="prev">&larr; prev</button>
+<button id="next">next &rarr;</button>
+<span id="counter"></span>
+<p id="caption"></p>
+</div>
+{{range .Frames}}<div class="frame{{if not .Final}} step{{end}}" data-caption="{{.Caption}}" hidden>
+{{.Tree}}</div>
+{{end}}<script>
+(function() {
+  var frames = document.querySelectorAll(".frame");
+  var current = 0;
+  function show(i) {
+    if (i < 0 || i >= frames.length) {
+      return;
+    }
+    frames[current].hidden = true;
+    current = i;
+    frames[current].hidden = false;
+    document.getElementById("counter").textContent = (current + 1) + " / " + frames.length;
+    document.getElementById("caption").textContent = frames[current].dataset.caption;
+  }
+  document.getElementById("prev").onclick = function() { show(current - 1); };
+  document.getElementById("next").onclick = function() { show(current + 1); };
+  document.addEventListener("keydown", function(e) {
+    if (e.key === "ArrowLeft") { show(current - 1); }
+    if (e.key === "ArrowRight") { show(current + 1); }
+  });
+  frames[0].hidden = false;
+  show(0);
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseOps(t *testing.T) {
+	got, err := parseOps("i10, d-3\ti7")
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	want := []op{{insert: true, key: 10}, {insert: false, key: -3}, {insert: true, key: 7}}
+	if len(want) != len(got) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Errorf("want %v, got %v", want[i], got[i])
+		}
+	}
+
+	for _, s := range []string{"", "x1", "i", "iabc"} {
+		if _, err := parseOps(s); err == nil {
+			t.Errorf("got no error for '%v'", s)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		tree string
+		want []string
+	}{
+		{
+			tree: "avl",
+			want: []string{
+				`data-caption="Insert(3): step 3/3: rotateLeft at 2 (h=2)"`,
+				`data-caption="Delete(2): done"`,
+			},
+		},
+		{
+			tree: "llrb",
+			want: []string{
+				`data-caption="Insert(2): step 2/2: rotateLeft at 2"`,
+				`data-caption="Delete(2): done"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tree, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "page.html")
+			if err := run(tt.tree, "", out, "i1 i2 i3 d2"); err != nil {
+				t.Fatalf("got an error '%v'", err)
+			}
+
+			b, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatalf("got an error '%v'", err)
+			}
+			got := string(b)
+			for _, want := range append(tt.want, "<title>"+tt.tree+": i1 i2 i3 d2</title>") {
+				if !strings.Contains(got, want) {
+					t.Errorf("want %q in\n%v", want, got)
+				}
+			}
+		})
+	}
+
+	if err := run("splay", "", "", "i1"); err == nil {
+		t.Errorf("got no error for an unknown tree")
+	}
+}
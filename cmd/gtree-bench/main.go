@@ -0,0 +1,248 @@
+/*
+	Command gtree-bench runs workloads against the trees of gtree and
+	reports their throughput, allocations and memory usage.
+
+	Usage:
+
+		gtree-bench [-impl avl,llrb,...] [-workload sequential,ycsb-a,...]
+		            [-n 100000] [-ops 100000] [-seed 1] [-format csv|json]
+
+	Each workload optionally loads n keys, which is not measured, and then
+	runs its operations. Operations are generated before the measurement,
+	so that only the trees are measured. A result reports:
+
+		ns_per_op      elapsed time per operation
+		ops_per_sec    operations per second
+		allocs_per_op  heap allocations per operation
+		bytes_per_node heap bytes of a tree holding n random keys, per key
+
+	Run gtree-bench -list for the implementations and the workloads.
+*/
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/masa-suzu/gtree/internal/registry"
+)
+
+// result is a measurement of a workload on an implementation.
+type result struct {
+	Impl         string  `json:"impl"`
+	Workload     string  `json:"workload"`
+	N            int     `json:"n"`
+	Ops          int     `json:"ops"`
+	NsPerOp      float64 `json:"ns_per_op"`
+	OpsPerSec    float64 `json:"ops_per_sec"`
+	AllocsPerOp  float64 `json:"allocs_per_op"`
+	BytesPerNode float64 `json:"bytes_per_node"`
+
+	// count and found are compared between implementations.
+	count int
+	found int
+}
+
+type config struct {
+	impls     []registry.Implementation
+	workloads []workload
+	n         int
+	ops       int
+	seed      int64
+	format    string
+}
+
+func main() {
+	impls := flag.String("impl", "", "comma-separated implementations (default: all)")
+	names := flag.String("workload", "", "comma-separated workloads (default: all)")
+	n := flag.Int("n", 100000, "num of keys")
+	ops := flag.Int("ops", 0, "num of operations of workloads mixing operations (default: n)")
+	seed := flag.Int64("seed", 1, "seed of generated workloads")
+	format := flag.String("format", "csv", "output format: csv or json")
+	list := flag.Bool("list", false, "list the implementations and the workloads")
+	flag.Parse()
+
+	if *list {
+		printList(os.Stdout)
+		return
+	}
+
+	c, err := newConfig(*impls, *names, *n, *ops, *seed, *format)
+	if err == nil {
+		err = run(os.Stdout, c)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gtree-bench:", err)
+		os.Exit(1)
+	}
+}
+
+func printList(w io.Writer) {
+	fmt.Fprintln(w, "implementations:")
+	for _, impl := range registry.All {
+		fmt.Fprintf(w, "\t%v\n", impl.Name)
+	}
+	fmt.Fprintln(w, "workloads:")
+	for _, wl := range workloads {
+		fmt.Fprintf(w, "\t%-12v%v\n", wl.name, wl.doc)
+	}
+}
+
+func newConfig(impls, names string, n, ops int, seed int64, format string) (*config, error) {
+	if n < 2 {
+		return nil, fmt.Errorf("n must be at least 2, got %v", n)
+	}
+	if ops <= 0 {
+		ops = n
+	}
+	if format != "csv" && format != "json" {
+		return nil, fmt.Errorf("unknown format '%v'", format)
+	}
+
+	c := &config{n: n, ops: ops, seed: seed, format: format}
+
+	c.impls = registry.All
+	if impls != "" {
+		c.impls = nil
+		for _, name := range strings.Split(impls, ",") {
+			impl, ok := registry.Find(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown implementation '%v'", name)
+			}
+			c.impls = append(c.impls, impl)
+		}
+	}
+
+	c.workloads = workloads
+	if names != "" {
+		c.workloads = nil
+		for _, name := range strings.Split(names, ",") {
+			wl, ok := findWorkload(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown workload '%v'", name)
+			}
+			c.workloads = append(c.workloads, wl)
+		}
+	}
+	return c, nil
+}
+
+func findWorkload(name string) (workload, bool) {
+	for _, wl := range workloads {
+		if wl.name == name {
+			return wl, true
+		}
+	}
+	return workload{}, false
+}
+
+func run(w io.Writer, c *config) error {
+	results := []result{}
+	first := map[string]result{}
+
+	for _, impl := range c.impls {
+		bytes := bytesPerNode(impl, c.n, c.seed)
+
+		for _, wl := range c.workloads {
+			load, ops := wl.gen(rand.New(rand.NewSource(c.seed)), c.n, c.ops)
+			r := measure(impl, load, ops)
+			r.Workload = wl.name
+			r.N = c.n
+			r.BytesPerNode = bytes
+			results = append(results, r)
+
+			f, ok := first[wl.name]
+			if !ok {
+				first[wl.name] = r
+				continue
+			}
+			if r.count != f.count || r.found != f.found {
+				return fmt.Errorf("%v: %v ended with %v keys and found %v keys, but %v ended with %v keys and found %v keys",
+					wl.name, r.Impl, r.count, r.found, f.Impl, f.count, f.found)
+			}
+		}
+	}
+
+	if c.format == "json" {
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(results)
+	}
+	return writeCSV(w, results)
+}
+
+func measure(impl registry.Implementation, load, ops []op) result {
+	t := impl.New()
+	apply(t, load)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+	found := apply(t, ops)
+	elapsed := time.Since(start)
+
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(t)
+
+	return result{
+		Impl:        impl.Name,
+		Ops:         len(ops),
+		NsPerOp:     float64(elapsed.Nanoseconds()) / float64(len(ops)),
+		OpsPerSec:   float64(len(ops)) / elapsed.Seconds(),
+		AllocsPerOp: float64(after.Mallocs-before.Mallocs) / float64(len(ops)),
+		count:       t.Count(),
+		found:       found,
+	}
+}
+
+// bytesPerNode returns the heap bytes of a tree holding n random keys,
+// divided by n.
+func bytesPerNode(impl registry.Implementation, n int, seed int64) float64 {
+	load, _ := loadShuffled(rand.New(rand.NewSource(seed)), n)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	t := impl.New()
+	apply(t, load)
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(t)
+
+	if after.HeapAlloc < before.HeapAlloc {
+		return 0
+	}
+	return float64(after.HeapAlloc-before.HeapAlloc) / float64(n)
+}
+
+func writeCSV(w io.Writer, results []result) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"impl", "workload", "n", "ops", "ns_per_op", "ops_per_sec", "allocs_per_op", "bytes_per_node"})
+	for _, r := range results {
+		cw.Write([]string{
+			r.Impl,
+			r.Workload,
+			strconv.Itoa(r.N),
+			strconv.Itoa(r.Ops),
+			strconv.FormatFloat(r.NsPerOp, 'f', 1, 64),
+			strconv.FormatFloat(r.OpsPerSec, 'f', 0, 64),
+			strconv.FormatFloat(r.AllocsPerOp, 'f', 2, 64),
+			strconv.FormatFloat(r.BytesPerNode, 'f', 1, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+
+	"github.com/masa-suzu/gtree/internal/registry"
+)
+
+func TestRun_CSV(t *testing.T) {
+	c, err := newConfig("", "", 200, 0, 1, "csv")
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	w := &bytes.Buffer{}
+	if err := run(w, c); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	records, err := csv.NewReader(w).ReadAll()
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	if want := 1 + len(registry.All)*len(workloads); want != len(records) {
+		t.Errorf("want %v records, got %v", want, len(records))
+	}
+	if got := records[1][:4]; got[0] != "avl" || got[1] != "sequential" || got[2] != "200" || got[3] != "600" {
+		t.Errorf("want [avl sequential 200 600], got %v", got)
+	}
+}
+
+func TestRun_JSON(t *testing.T) {
+	c, err := newConfig("llrb,btree", "ycsb-a,ycsb-e", 100, 50, 1, "json")
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	w := &bytes.Buffer{}
+	if err := run(w, c); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	results := []result{}
+	if err := json.Unmarshal(w.Bytes(), &results); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	want := []struct{ impl, workload string }{
+		{"llrb", "ycsb-a"}, {"llrb", "ycsb-e"}, {"btree", "ycsb-a"}, {"btree", "ycsb-e"},
+	}
+	if len(want) != len(results) {
+		t.Fatalf("want %v results, got %v", len(want), len(results))
+	}
+	for i, r := range results {
+		if r.Impl != want[i].impl || r.Workload != want[i].workload || r.Ops != 50 {
+			t.Errorf("want %v on %v with 50 ops, got %+v", want[i].impl, want[i].workload, r)
+		}
+		if r.NsPerOp <= 0 || r.BytesPerNode <= 0 {
+			t.Errorf("want positive measurements, got %+v", r)
+		}
+	}
+}
+
+func TestNewConfig_Error(t *testing.T) {
+	tests := []struct {
+		impls, workloads, format string
+		n                        int
+	}{
+		{impls: "nope", format: "csv", n: 10},
+		{workloads: "ycsb-z", format: "csv", n: 10},
+		{format: "xml", n: 10},
+		{format: "csv", n: 1},
+	}
+
+	for _, tt := range tests {
+		if _, err := newConfig(tt.impls, tt.workloads, tt.n, 0, 1, tt.format); err == nil {
+			t.Errorf("got no error for %+v", tt)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"math/rand"
+
+	"github.com/masa-suzu/gtree/internal/registry"
+)
+
+type opKind byte
+
+const (
+	opInsert opKind = iota
+	opSearch
+	opDelete
+	opReadModifyWrite // Search and Insert the same key
+	opScan            // Search n consecutive keys
+)
+
+type op struct {
+	kind opKind
+	key  int
+	n    int // num of keys of opScan
+}
+
+// workload is a sequence of operations on a tree.
+// The load phase fills the tree and is not measured.
+type workload struct {
+	name string
+	doc  string
+	gen  func(r *rand.Rand, n, ops int) (load, run []op)
+}
+
+var workloads = []workload{
+	{
+		name: "sequential",
+		doc:  "insert, search and delete keys 1..n in ascending order",
+		gen: func(r *rand.Rand, n, ops int) ([]op, []op) {
+			keys := make([]int, n)
+			for i := range keys {
+				keys[i] = i + 1
+			}
+			return nil, insertSearchDelete(keys)
+		},
+	},
+	{
+		name: "reverse",
+		doc:  "insert, search and delete keys n..1 in descending order",
+		gen: func(r *rand.Rand, n, ops int) ([]op, []op) {
+			keys := make([]int, n)
+			for i := range keys {
+				keys[i] = n - i
+			}
+			return nil, insertSearchDelete(keys)
+		},
+	},
+	{
+		name: "uniform",
+		doc:  "insert, search and delete keys 0..n-1 in shuffled orders",
+		gen: func(r *rand.Rand, n, ops int) ([]op, []op) {
+			run := make([]op, 0, 3*n)
+			for _, k := range r.Perm(n) {
+				run = append(run, op{kind: opInsert, key: k})
+			}
+			for _, k := range r.Perm(n) {
+				run = append(run, op{kind: opSearch, key: k})
+			}
+			for _, k := range r.Perm(n) {
+				run = append(run, op{kind: opDelete, key: k})
+			}
+			return nil, run
+		},
+	},
+	{
+		name: "zipf",
+		doc:  "search Zipf-distributed keys of n loaded keys",
+		gen: func(r *rand.Rand, n, ops int) ([]op, []op) {
+			load, keys := loadShuffled(r, n)
+			z := newZipf(r, keys)
+			run := make([]op, ops)
+			for i := range run {
+				run[i] = op{kind: opSearch, key: z()}
+			}
+			return load, run
+		},
+	},
+	ycsb("ycsb-a", "YCSB A: 50% search, 50% update of Zipf-distributed keys", 50, 50, 0, 0, 0),
+	ycsb("ycsb-b", "YCSB B: 95% search, 5% update of Zipf-distributed keys", 95, 5, 0, 0, 0),
+	ycsb("ycsb-c", "YCSB C: search Zipf-distributed keys", 100, 0, 0, 0, 0),
+	ycsb("ycsb-d", "YCSB D: 95% search of the latest keys, 5% insert", 95, 0, 5, 0, 0),
+	ycsb("ycsb-e", "YCSB E: 95% scan of 1-10 consecutive keys by searches, 5% insert", 0, 0, 5, 95, 0),
+	ycsb("ycsb-f", "YCSB F: 50% search, 50% read-modify-write of Zipf-distributed keys", 50, 0, 0, 0, 50),
+}
+
+func insertSearchDelete(keys []int) []op {
+	run := make([]op, 0, 3*len(keys))
+	for _, kind := range []opKind{opInsert, opSearch, opDelete} {
+		for _, k := range keys {
+			run = append(run, op{kind: kind, key: k})
+		}
+	}
+	return run
+}
+
+// loadShuffled inserts keys 0..n-1 in a shuffled order.
+func loadShuffled(r *rand.Rand, n int) ([]op, []int) {
+	keys := r.Perm(n)
+	load := make([]op, n)
+	for i, k := range keys {
+		load[i] = op{kind: opInsert, key: k}
+	}
+	return load, keys
+}
+
+// newZipf returns a generator of keys whose ranks follow a Zipf
+// distribution. Ranks are mapped to shuffled keys, so hot keys are not
+// adjacent.
+func newZipf(r *rand.Rand, keys []int) func() int {
+	z := rand.NewZipf(r, 1.1, 1, uint64(len(keys)-1))
+	return func() int {
+		return keys[z.Uint64()]
+	}
+}
+
+// ycsb returns a workload similar to a YCSB core workload,
+// mixing operations by the given percentages.
+// Inserted keys are new keys n, n+1, ..., and in a workload with inserts,
+// searches go to the latest keys as YCSB D does.
+func ycsb(name, doc string, search, update, insert, scan, rmw int) workload {
+	return workload{
+		name: name,
+		doc:  doc,
+		gen: func(r *rand.Rand, n, ops int) ([]op, []op) {
+			load, keys := loadShuffled(r, n)
+			z := newZipf(r, keys)
+			next := n
+
+			pick := z
+			if insert > 0 {
+				latest := rand.NewZipf(r, 1.1, 1, uint64(n-1))
+				pick = func() int {
+					return next - 1 - int(latest.Uint64())
+				}
+			}
+
+			run := make([]op, ops)
+			for i := range run {
+				x := r.Intn(100)
+				switch {
+				case x < search:
+					run[i] = op{kind: opSearch, key: pick()}
+				case x < search+update:
+					run[i] = op{kind: opInsert, key: pick()}
+				case x < search+update+insert:
+					run[i] = op{kind: opInsert, key: next}
+					next++
+				case x < search+update+insert+scan:
+					run[i] = op{kind: opScan, key: pick(), n: 1 + r.Intn(10)}
+				default:
+					run[i] = op{kind: opReadModifyWrite, key: pick()}
+				}
+			}
+			return load, run
+		},
+	}
+}
+
+// apply runs ops on t and returns num of found keys.
+func apply(t registry.KVS, ops []op) int {
+	found := 0
+	for _, o := range ops {
+		switch o.kind {
+		case opInsert:
+			t.Insert(o.key, o.key)
+		case opSearch:
+			if _, err := t.Search(o.key); err == nil {
+				found++
+			}
+		case opDelete:
+			t.Delete(o.key)
+		case opReadModifyWrite:
+			if v, err := t.Search(o.key); err == nil {
+				found++
+				t.Insert(o.key, v)
+			}
+		case opScan:
+			for k := o.key; k < o.key+o.n; k++ {
+				if _, err := t.Search(k); err == nil {
+					found++
+				}
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,130 @@
+/*
+	Command gtree-replay replays a trace recorded by trace.Recorder against
+	the trees of gtree, checks the results of searches and reports the
+	time of each phase.
+
+	Usage:
+
+		gtree-replay [-impl avl,llrb,...] [-nocheck] trace
+
+	The trace is decoded before the replay, so that only the trees are
+	timed. A tree which cannot hold a key of the trace, such as veb, is
+	skipped. A tree returning a result different from the trace is
+	reported, and the others are still replayed. It exits with 1 when any
+	tree returns a result different from the trace.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/masa-suzu/gtree/internal/registry"
+	"github.com/masa-suzu/gtree/trace"
+)
+
+func main() {
+	impls := flag.String("impl", "", "comma-separated implementations (default: all)")
+	noCheck := flag.Bool("nocheck", false, "do not check the results of searches")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: gtree-replay [-impl avl,llrb,...] [-nocheck] trace")
+		os.Exit(2)
+	}
+
+	if err := run(os.Stdout, flag.Arg(0), *impls, *noCheck); err != nil {
+		fmt.Fprintln(os.Stderr, "gtree-replay:", err)
+		os.Exit(1)
+	}
+}
+
+func run(w io.Writer, path, impls string, noCheck bool) error {
+	selected := registry.All
+	if impls != "" {
+		selected = nil
+		for _, name := range strings.Split(impls, ",") {
+			impl, ok := registry.Find(name)
+			if !ok {
+				return fmt.Errorf("unknown implementation '%v'", name)
+			}
+			selected = append(selected, impl)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	ops, err := trace.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "impl\tphase\tinserts\tdeletes\tsearches\ttime\tns/op")
+
+	var failed []string
+
+	for _, impl := range selected {
+		if k, ok := outOfRange(impl, ops); ok {
+			fmt.Fprintf(tw, "%v\tskipped: key '%v' is out of range\n", impl.Name, k)
+			continue
+		}
+
+		report, err := trace.Replay(ops, impl.New(), &trace.ReplayOptions{NoCheck: noCheck})
+		if err != nil {
+			fmt.Fprintf(tw, "%v\t%v\n", impl.Name, err)
+			failed = append(failed, impl.Name)
+			continue
+		}
+
+		for _, p := range report.Phases {
+			name := p.Name
+			if name == "" {
+				name = "-"
+			}
+			fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+				impl.Name, name, p.Inserts, p.Deletes, p.Searches, p.Duration, nsPerOp(p.Duration, p.Ops()))
+		}
+		total := 0
+		for _, p := range report.Phases {
+			total += p.Ops()
+		}
+		fmt.Fprintf(tw, "%v\ttotal\t\t\t\t%v\t%v\n", impl.Name, report.Duration(), nsPerOp(report.Duration(), total))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("results of %v differ from the trace", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// outOfRange returns the first inserted key of ops which impl cannot hold.
+func outOfRange(impl registry.Implementation, ops []trace.Op) (int, bool) {
+	if impl.InRange == nil {
+		return 0, false
+	}
+	for _, o := range ops {
+		if o.Kind == trace.Insert && !impl.InRange(o.Key) {
+			return o.Key, true
+		}
+	}
+	return 0, false
+}
+
+func nsPerOp(d time.Duration, ops int) string {
+	if ops == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", float64(d.Nanoseconds())/float64(ops))
+}
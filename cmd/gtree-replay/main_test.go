@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/masa-suzu/gtree/avl"
+	"github.com/masa-suzu/gtree/trace"
+)
+
+func writeTrace(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "trace")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	defer f.Close()
+
+	r := trace.NewRecorder(avl.New(), f)
+	r.Phase("load")
+	for i := 0; i < 100; i++ {
+		r.Insert(i, i)
+	}
+	r.Phase("serve")
+	for i := 0; i < 200; i += 2 {
+		r.Search(i)
+		r.Delete(i + 1)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	w := &bytes.Buffer{}
+	if err := run(w, writeTrace(t), "avl,btree", false); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	if len(lines) != 1+2*3 {
+		t.Fatalf("want 7 lines, got\n%v", w.String())
+	}
+	for i, want := range [][]string{
+		{"avl", "load", "100", "0", "0"},
+		{"avl", "serve", "0", "100", "100"},
+		{"avl", "total"},
+		{"btree", "load", "100", "0", "0"},
+	} {
+		got := strings.Fields(lines[1+i])
+		if strings.Join(got[:len(want)], " ") != strings.Join(want, " ") {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	}
+}
+
+func TestRun_OutOfRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	r := trace.NewRecorder(avl.New(), f)
+	r.Insert(-1, 1)
+	r.Search(-1)
+	if err := r.Close(); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	f.Close()
+
+	w := &bytes.Buffer{}
+	if err := run(w, path, "veb,avl", false); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	if len(lines) != 1+1+2 {
+		t.Fatalf("want 4 lines, got\n%v", w.String())
+	}
+	if got := strings.Join(strings.Fields(lines[1]), " "); got != "veb skipped: key '-1' is out of range" {
+		t.Errorf("veb must be skipped, got %v", got)
+	}
+	if got := strings.Fields(lines[3]); got[0] != "avl" || got[1] != "total" {
+		t.Errorf("avl must be replayed, got %v", got)
+	}
+}
+
+func TestRun_Mismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	// btree keeps no value larger than a quarter of a page
+	r := trace.NewRecorder(avl.New(), f)
+	r.Insert(1, strings.Repeat("v", 2000))
+	r.Search(1)
+	if err := r.Close(); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	f.Close()
+
+	w := &bytes.Buffer{}
+	if err := run(w, path, "btree,avl", false); err == nil {
+		t.Errorf("got no error for a mismatch")
+	}
+
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	if len(lines) != 1+1+2 {
+		t.Fatalf("want 4 lines, got\n%v", w.String())
+	}
+	if got := strings.Fields(lines[1]); got[0] != "btree" || !strings.Contains(lines[1], "returned no value") {
+		t.Errorf("mismatch of btree must be reported, got %v", lines[1])
+	}
+	if got := strings.Fields(lines[3]); got[0] != "avl" || got[1] != "total" {
+		t.Errorf("avl must be replayed, got %v", got)
+	}
+}
+
+func TestRun_Error(t *testing.T) {
+	path := writeTrace(t)
+
+	if err := run(&bytes.Buffer{}, path, "nope", false); err == nil {
+		t.Errorf("got no error for an unknown implementation")
+	}
+	if err := run(&bytes.Buffer{}, filepath.Join(t.TempDir(), "missing"), "", false); err == nil {
+		t.Errorf("got no error for a missing trace")
+	}
+}
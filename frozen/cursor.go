@@ -0,0 +1,62 @@
+package frozen
+
+// Cursor points at an entry of an Index, or past its ends.
+// Next and Prev take O(1) amortized time without allocations:
+//
+//	for c := index.First(); c.Valid(); c.Next() {
+//		fmt.Println(c.Key(), c.Value())
+//	}
+type Cursor struct {
+	x *Index
+	i int
+}
+
+// First returns a cursor at the entry of the smallest key.
+func (x *Index) First() Cursor {
+	return Cursor{x: x, i: x.first()}
+}
+
+// Last returns a cursor at the entry of the largest key.
+func (x *Index) Last() Cursor {
+	return Cursor{x: x, i: x.last()}
+}
+
+// Seek returns a cursor at the entry of the smallest key not less than key.
+func (x *Index) Seek(key int) Cursor {
+	return Cursor{x: x, i: x.ceiling(key)}
+}
+
+// Valid reports whether the cursor points at an entry.
+func (c *Cursor) Valid() bool {
+	return c.i != 0
+}
+
+// Key returns the key of the entry. The cursor must be valid.
+func (c *Cursor) Key() int {
+	return c.x.keys[c.i]
+}
+
+// Value returns the value of the entry. The cursor must be valid.
+func (c *Cursor) Value() interface{} {
+	return c.x.values[c.i]
+}
+
+// Next moves the cursor to the next entry in ascending order of keys,
+// and reports whether it is valid.
+func (c *Cursor) Next() bool {
+	if c.i == 0 {
+		return false
+	}
+	c.i = c.x.next(c.i)
+	return c.i != 0
+}
+
+// Prev moves the cursor to the previous entry in ascending order of keys,
+// and reports whether it is valid.
+func (c *Cursor) Prev() bool {
+	if c.i == 0 {
+		return false
+	}
+	c.i = c.x.prev(c.i)
+	return c.i != 0
+}
@@ -0,0 +1,169 @@
+/*
+	Package frozen provides an immutable index of sorted keys for trees
+	which are built once and then only read.
+
+	Keys are laid out in an array in Eytzinger order, the order of a
+	breadth-first walk of a complete binary search tree: the children of
+	the key at i are at 2i and 2i+1. A search touches the same positions
+	as a walk down the tree, but follows no pointers, and the top levels of
+	all searches share a few cache lines.
+*/
+package frozen
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Index is an immutable map from int keys to values.
+type Index struct {
+	keys   []int // keys[1:] in Eytzinger order
+	values []interface{}
+}
+
+// New returns a reference to an Index holding keys[i] with values[i].
+// Keys must be in strictly ascending order.
+func New(keys []int, values []interface{}) (*Index, error) {
+	if len(keys) != len(values) {
+		return nil, fmt.Errorf("num of keys and values differ: %v and %v", len(keys), len(values))
+	}
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1] >= keys[i] {
+			return nil, fmt.Errorf("keys must be in strictly ascending order, got '%v' after '%v'", keys[i], keys[i-1])
+		}
+	}
+
+	x := &Index{
+		keys:   make([]int, len(keys)+1),
+		values: make([]interface{}, len(keys)+1),
+	}
+	i := x.first()
+	for j := range keys {
+		x.keys[i] = keys[j]
+		x.values[i] = values[j]
+		i = x.next(i)
+	}
+	return x, nil
+}
+
+// Count returns num of keys.
+func (x *Index) Count() int {
+	return len(x.keys) - 1
+}
+
+// Search returns a value associated with a given key.
+// If no value is found by the key, returns nil with an error.
+func (x *Index) Search(key int) (interface{}, error) {
+	if i := x.ceiling(key); i != 0 && x.keys[i] == key {
+		return x.values[i], nil
+	}
+	return nil, fmt.Errorf("found no value by key '%v'", key)
+}
+
+// Floor returns the largest key not greater than a given key and its value.
+// If there is no such key, returns an error.
+func (x *Index) Floor(key int) (int, interface{}, error) {
+	i := x.floor(key)
+	if i == 0 {
+		return 0, nil, fmt.Errorf("found no key not greater than '%v'", key)
+	}
+	return x.keys[i], x.values[i], nil
+}
+
+// Ceiling returns the smallest key not less than a given key and its value.
+// If there is no such key, returns an error.
+func (x *Index) Ceiling(key int) (int, interface{}, error) {
+	i := x.ceiling(key)
+	if i == 0 {
+		return 0, nil, fmt.Errorf("found no key not less than '%v'", key)
+	}
+	return x.keys[i], x.values[i], nil
+}
+
+// Ascend calls f for each key-value pair in ascending order of keys.
+// If f returns false, Ascend stops the iteration.
+func (x *Index) Ascend(f func(key int, value interface{}) bool) {
+	for i := x.first(); i != 0; i = x.next(i) {
+		if !f(x.keys[i], x.values[i]) {
+			return
+		}
+	}
+}
+
+// ceiling returns the position of the smallest key not less than key, or 0.
+// The walk goes right past keys less than key; the answer is the node
+// of the last turn to the left, found by dropping the trailing right turns
+// and the left turn from the bits of the final position.
+func (x *Index) ceiling(key int) int {
+	i := 1
+	for i < len(x.keys) {
+		if x.keys[i] < key {
+			i = 2*i + 1
+		} else {
+			i = 2 * i
+		}
+	}
+	return i >> (bits.TrailingZeros(^uint(i)) + 1)
+}
+
+// floor returns the position of the largest key not greater than key, or
+// 0, mirroring ceiling.
+func (x *Index) floor(key int) int {
+	i := 1
+	for i < len(x.keys) {
+		if x.keys[i] <= key {
+			i = 2*i + 1
+		} else {
+			i = 2 * i
+		}
+	}
+	return i >> (bits.TrailingZeros(uint(i)) + 1)
+}
+
+// first returns the position of the smallest key, or 0 for no keys.
+func (x *Index) first() int {
+	if len(x.keys) == 1 {
+		return 0
+	}
+	i := 1
+	for 2*i < len(x.keys) {
+		i = 2 * i
+	}
+	return i
+}
+
+// last returns the position of the largest key, or 0 for no keys.
+func (x *Index) last() int {
+	if len(x.keys) == 1 {
+		return 0
+	}
+	i := 1
+	for 2*i+1 < len(x.keys) {
+		i = 2*i + 1
+	}
+	return i
+}
+
+// next returns the position following i in ascending order, or 0.
+func (x *Index) next(i int) int {
+	if 2*i+1 < len(x.keys) {
+		i = 2*i + 1
+		for 2*i < len(x.keys) {
+			i = 2 * i
+		}
+		return i
+	}
+	return i >> (bits.TrailingZeros(^uint(i)) + 1)
+}
+
+// prev returns the position preceding i in ascending order, or 0.
+func (x *Index) prev(i int) int {
+	if 2*i < len(x.keys) {
+		i = 2 * i
+		for 2*i+1 < len(x.keys) {
+			i = 2*i + 1
+		}
+		return i
+	}
+	return i >> (bits.TrailingZeros(uint(i)) + 1)
+}
@@ -0,0 +1,138 @@
+package frozen_test
+
+import (
+	"testing"
+
+	"github.com/masa-suzu/gtree/frozen"
+)
+
+// index returns an Index of n keys 0, 2, ..., 2(n-1) with values 10 times
+// the keys.
+func index(t *testing.T, n int) *frozen.Index {
+	keys := make([]int, n)
+	values := make([]interface{}, n)
+	for i := range keys {
+		keys[i] = 2 * i
+		values[i] = 20 * i
+	}
+	x, err := frozen.New(keys, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return x
+}
+
+func TestIndex(t *testing.T) {
+	// every shape of the last level up to 5 levels
+	for n := 0; n < 64; n++ {
+		x := index(t, n)
+		if x.Count() != n {
+			t.Fatalf("n=%v: num of keys must be %v, got %v", n, n, x.Count())
+		}
+
+		for key := -1; key <= 2*n; key++ {
+			v, err := x.Search(key)
+			if found := key >= 0 && key%2 == 0 && key < 2*n; found != (err == nil) || found && v != 10*key {
+				t.Errorf("n=%v: Search(%v) got %v, %v", n, key, v, err)
+			}
+
+			// floor and ceiling among 0, 2, ..., 2(n-1)
+			floor, ceiling := -1, 2*n
+			for k := 0; k < 2*n; k += 2 {
+				if k <= key {
+					floor = k
+				}
+				if k >= key && ceiling == 2*n {
+					ceiling = k
+				}
+			}
+			k, v, err := x.Floor(key)
+			if ok := floor >= 0; ok != (err == nil) || ok && (k != floor || v != 10*floor) {
+				t.Errorf("n=%v: Floor(%v) got %v, %v, %v", n, key, k, v, err)
+			}
+			k, v, err = x.Ceiling(key)
+			if ok := ceiling < 2*n; ok != (err == nil) || ok && (k != ceiling || v != 10*ceiling) {
+				t.Errorf("n=%v: Ceiling(%v) got %v, %v, %v", n, key, k, v, err)
+			}
+		}
+
+		want := 0
+		x.Ascend(func(key int, value interface{}) bool {
+			if key != want || value != 10*key {
+				t.Errorf("n=%v: want %v, got %v:%v", n, want, key, value)
+			}
+			want += 2
+			return true
+		})
+		if want != 2*n {
+			t.Errorf("n=%v: Ascend stopped at %v", n, want)
+		}
+	}
+}
+
+func TestIndex_Cursor(t *testing.T) {
+	for n := 0; n < 64; n++ {
+		x := index(t, n)
+
+		want := 0
+		for c := x.First(); c.Valid(); c.Next() {
+			if c.Key() != want || c.Value() != 10*want {
+				t.Fatalf("n=%v: want %v, got %v:%v", n, want, c.Key(), c.Value())
+			}
+			want += 2
+		}
+		if want != 2*n {
+			t.Errorf("n=%v: Next stopped at %v", n, want)
+		}
+
+		for c := x.Last(); c.Valid(); c.Prev() {
+			want -= 2
+			if c.Key() != want {
+				t.Fatalf("n=%v: want %v, got %v", n, want, c.Key())
+			}
+		}
+		if want != 0 {
+			t.Errorf("n=%v: Prev stopped at %v", n, want)
+		}
+
+		if c := x.Seek(3); n >= 3 && c.Key() != 4 || n < 3 && c.Valid() {
+			t.Errorf("n=%v: Seek(3) got %v", n, c)
+		}
+	}
+}
+
+func TestIndex_Allocs(t *testing.T) {
+	x := index(t, 1000)
+
+	got := testing.AllocsPerRun(10, func() {
+		for key := 0; key < 2000; key += 2 {
+			_, _ = x.Search(key)
+			_, _, _ = x.Floor(key + 1)
+		}
+		for c := x.First(); c.Valid(); c.Next() {
+		}
+	})
+	if got != 0 {
+		t.Errorf("want no allocations, got %v", got)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   []int
+		values []interface{}
+	}{
+		{name: "length", keys: []int{1, 2}, values: []interface{}{nil}},
+		{name: "descending", keys: []int{2, 1}, values: []interface{}{nil, nil}},
+		{name: "duplicate", keys: []int{1, 1}, values: []interface{}{nil, nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := frozen.New(tt.keys, tt.values); err == nil {
+				t.Errorf("got no error")
+			}
+		})
+	}
+}
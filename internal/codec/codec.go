@@ -0,0 +1,112 @@
+/*
+	Package codec encodes the values stored in gtree trees so that they
+	can be persisted by the disk-backed packages.
+*/
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	tagNil byte = iota
+	tagInt
+	tagString
+	tagBytes
+	tagBool
+	tagFloat
+	tagGob
+)
+
+// ErrCorrupt is returned when an encoded value cannot be decoded.
+var ErrCorrupt = errors.New("codec: corrupt value")
+
+// AppendValue appends the encoding of v to b.
+// Values other than nil, int, string, []byte, bool and float64 are
+// encoded with encoding/gob, so their types must be registered by gob.Register.
+func AppendValue(b []byte, v interface{}) ([]byte, error) {
+	switch x := v.(type) {
+	case nil:
+		return append(b, tagNil), nil
+	case int:
+		b = append(b, tagInt)
+		return binary.AppendVarint(b, int64(x)), nil
+	case string:
+		b = append(b, tagString)
+		b = binary.AppendUvarint(b, uint64(len(x)))
+		return append(b, x...), nil
+	case []byte:
+		b = append(b, tagBytes)
+		b = binary.AppendUvarint(b, uint64(len(x)))
+		return append(b, x...), nil
+	case bool:
+		if x {
+			return append(b, tagBool, 1), nil
+		}
+		return append(b, tagBool, 0), nil
+	case float64:
+		b = append(b, tagFloat)
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(x)), nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(&v); err != nil {
+		return nil, fmt.Errorf("codec: %v", err)
+	}
+	b = append(b, tagGob)
+	b = binary.AppendUvarint(b, uint64(buf.Len()))
+	return append(b, buf.Bytes()...), nil
+}
+
+// DecodeValue decodes a value from the head of b.
+// It returns the value and the number of bytes consumed.
+func DecodeValue(b []byte) (interface{}, int, error) {
+	if len(b) == 0 {
+		return nil, 0, ErrCorrupt
+	}
+
+	switch b[0] {
+	case tagNil:
+		return nil, 1, nil
+	case tagInt:
+		x, n := binary.Varint(b[1:])
+		if n <= 0 {
+			return nil, 0, ErrCorrupt
+		}
+		return int(x), 1 + n, nil
+	case tagString, tagBytes, tagGob:
+		l, n := binary.Uvarint(b[1:])
+		if n <= 0 || uint64(len(b)-1-n) < l {
+			return nil, 0, ErrCorrupt
+		}
+		p := b[1+n : 1+n+int(l)]
+		size := 1 + n + int(l)
+		switch b[0] {
+		case tagString:
+			return string(p), size, nil
+		case tagBytes:
+			return append([]byte{}, p...), size, nil
+		}
+		var v interface{}
+		if err := gob.NewDecoder(bytes.NewReader(p)).Decode(&v); err != nil {
+			return nil, 0, fmt.Errorf("codec: %v", err)
+		}
+		return v, size, nil
+	case tagBool:
+		if len(b) < 2 {
+			return nil, 0, ErrCorrupt
+		}
+		return b[1] == 1, 2, nil
+	case tagFloat:
+		if len(b) < 9 {
+			return nil, 0, ErrCorrupt
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b[1:])), 9, nil
+	}
+	return nil, 0, ErrCorrupt
+}
@@ -0,0 +1,74 @@
+package codec_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/masa-suzu/gtree/internal/codec"
+)
+
+type point struct {
+	X, Y int
+}
+
+func init() {
+	gob.Register(point{})
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{name: "nil", v: nil},
+		{name: "int", v: -12345},
+		{name: "string", v: "<script>"},
+		{name: "bool", v: true},
+		{name: "float", v: 3.5},
+		{name: "gob", v: point{X: 1, Y: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := codec.AppendValue([]byte{0xff}, tt.v)
+			if err != nil {
+				t.Fatalf("got an error '%v'", err)
+			}
+
+			got, n, err := codec.DecodeValue(b[1:])
+			if err != nil {
+				t.Fatalf("got an error '%v'", err)
+			}
+			if n != len(b)-1 {
+				t.Errorf("want %v bytes consumed, got %v", len(b)-1, n)
+			}
+			if tt.v != got {
+				t.Errorf("want %v, got %v", tt.v, got)
+			}
+		})
+	}
+}
+
+func TestRoundTrip_Bytes(t *testing.T) {
+	want := []byte("abc")
+	b, _ := codec.AppendValue(nil, want)
+
+	got, _, err := codec.DecodeValue(b)
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	if !bytes.Equal(want, got.([]byte)) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestDecode_Truncated(t *testing.T) {
+	b, _ := codec.AppendValue(nil, "hello")
+
+	for i := 0; i < len(b); i++ {
+		if _, _, err := codec.DecodeValue(b[:i]); err == nil {
+			t.Errorf("got no error for %v bytes", i)
+		}
+	}
+}
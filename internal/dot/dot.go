@@ -0,0 +1,135 @@
+/*
+	Package dot writes binary search trees in the Graphviz DOT language.
+
+	Nodes are drawn as circles labeled by their keys. Invisible placeholders
+	stand for missing children, so that a single child is drawn on its own
+	side, and the path of a search can be highlighted.
+*/
+package dot
+
+import (
+	"fmt"
+	"io"
+)
+
+// Node is a node to be written.
+type Node struct {
+	Key int
+	// Label is escaped by Write, so that a newline breaks the label.
+	Label string
+	// Attrs are extra attributes of the node, e.g. `color="red"`.
+	Attrs []string
+	// EdgeAttrs are attributes of the edge from the parent.
+	EdgeAttrs []string
+	// Children are the left and right children, or empty for a leaf.
+	Children []*Node
+}
+
+// Write writes a digraph of a given name holding root.
+// root may be nil for an empty tree. If search is not nil, the nodes
+// visited by a search for *search and the edges between them are
+// highlighted.
+func Write(w io.Writer, name string, root *Node, search *int) error {
+	d := &writer{w: w, path: onPath(root, search)}
+	d.printf("digraph %q {\n", name)
+	d.printf("\tnode [shape=circle, fontname=\"arial\"];\n")
+	d.node(root)
+	d.printf("}\n")
+	return d.err
+}
+
+// writer keeps the first error of writes.
+type writer struct {
+	w    io.Writer
+	path map[*Node]bool
+	err  error
+}
+
+func (d *writer) node(n *Node) {
+	if n == nil {
+		return
+	}
+
+	attrs := ""
+	for _, a := range n.Attrs {
+		attrs += ", " + a
+	}
+	if d.path[n] {
+		attrs += ", style=filled, fillcolor=\"lightblue\""
+	}
+	d.printf("\t%q [label=\"%v\"%v];\n", id(n), escape(n.Label), attrs)
+
+	for i, c := range n.Children {
+		if c == nil {
+			p := fmt.Sprintf("%v.%v", id(n), i)
+			d.printf("\t%q [style=invis];\n", p)
+			d.printf("\t%q -> %q [style=invis];\n", id(n), p)
+			continue
+		}
+
+		attrs := c.EdgeAttrs
+		if d.path[n] && d.path[c] {
+			attrs = append(attrs[:len(attrs):len(attrs)], "penwidth=3")
+		}
+		edge := ""
+		for i, a := range attrs {
+			if i > 0 {
+				edge += ", "
+			}
+			edge += a
+		}
+		if edge != "" {
+			edge = " [" + edge + "]"
+		}
+		d.printf("\t%q -> %q%v;\n", id(n), id(c), edge)
+		d.node(c)
+	}
+}
+
+func (d *writer) printf(format string, a ...interface{}) {
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.w, format, a...)
+}
+
+// onPath returns the nodes visited by a search for *key.
+func onPath(n *Node, key *int) map[*Node]bool {
+	path := map[*Node]bool{}
+	if key == nil {
+		return path
+	}
+
+	for n != nil {
+		path[n] = true
+		if *key == n.Key || len(n.Children) == 0 {
+			break
+		}
+		if *key < n.Key {
+			n = n.Children[0]
+		} else {
+			n = n.Children[1]
+		}
+	}
+	return path
+}
+
+func id(n *Node) string {
+	return fmt.Sprintf("n%v", n.Key)
+}
+
+// escape escapes s for a double-quoted DOT string.
+func escape(s string) string {
+	b := []byte{}
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\\':
+			b = append(b, '\\')
+		case '\n':
+			b = append(b, '\\', 'n')
+			continue
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
@@ -0,0 +1,182 @@
+/*
+	Package fuzztest holds the differential fuzz test shared by the tree
+	packages. It runs random operation sequences against a tree and a
+	reference model and compares them after every step.
+
+	Each tree package has a FuzzDifferential target calling Run, e.g.
+
+		go test ./avl -fuzz FuzzDifferential
+
+	The fuzzer minimizes a failing input and stores it under testdata of
+	the package, and the failure message lists the operations which
+	reproduce it.
+*/
+package fuzztest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Tree is the set of operations under test.
+type Tree interface {
+	Search(key int) (interface{}, error)
+	Insert(key int, value interface{})
+	Delete(key int)
+	Count() int
+	Ascend(f func(key int, value interface{}) bool)
+	Validate() error
+}
+
+const (
+	opInsert = iota
+	opDelete
+	opSearch
+	numOfOps
+)
+
+// keySpace is small so that random streams hit existing keys often.
+const keySpace = 64
+
+type op struct {
+	kind int
+	key  int
+}
+
+func (o op) String() string {
+	switch o.kind {
+	case opInsert:
+		return fmt.Sprintf("Insert(%v)", o.key)
+	case opDelete:
+		return fmt.Sprintf("Delete(%v)", o.key)
+	}
+	return fmt.Sprintf("Search(%v)", o.key)
+}
+
+// decode reads two bytes per operation: its kind and its key.
+func decode(b []byte) []op {
+	ops := []op{}
+	for i := 0; i+1 < len(b); i += 2 {
+		ops = append(ops, op{
+			kind: int(b[i]) % numOfOps,
+			key:  int(b[i+1]) % keySpace,
+		})
+	}
+	return ops
+}
+
+func encode(ops ...op) []byte {
+	b := []byte{}
+	for _, o := range ops {
+		b = append(b, byte(o.kind), byte(o.key))
+	}
+	return b
+}
+
+// model is a reference implementation on a map and a sorted slice of keys.
+type model struct {
+	values map[int]interface{}
+	keys   []int
+}
+
+func (m *model) insert(key int, value interface{}) {
+	if _, ok := m.values[key]; !ok {
+		i := sort.SearchInts(m.keys, key)
+		m.keys = append(m.keys, 0)
+		copy(m.keys[i+1:], m.keys[i:])
+		m.keys[i] = key
+	}
+	m.values[key] = value
+}
+
+func (m *model) delete(key int) {
+	if _, ok := m.values[key]; !ok {
+		return
+	}
+	i := sort.SearchInts(m.keys, key)
+	m.keys = append(m.keys[:i], m.keys[i+1:]...)
+	delete(m.values, key)
+}
+
+// Run adds the seed corpus to f and fuzzes trees made by newTree
+// against the model.
+func Run(f *testing.F, newTree func() Tree) {
+	asc, desc := []op{}, []op{}
+	for k := 1; k <= 6; k++ {
+		asc = append(asc, op{kind: opInsert, key: k})
+		desc = append(desc, op{kind: opInsert, key: 7 - k})
+	}
+	for k := 1; k <= 6; k++ {
+		asc = append(asc, op{kind: opDelete, key: k})
+		desc = append(desc, op{kind: opDelete, key: k})
+	}
+	f.Add(encode(asc...))
+	f.Add(encode(desc...))
+	f.Add(encode(
+		op{opInsert, 6}, op{opInsert, 10}, op{opInsert, 1}, op{opInsert, 9},
+		op{opInsert, 8}, op{opInsert, 2}, op{opInsert, 4}, op{opInsert, 11},
+		op{opDelete, 6}, op{opDelete, 10}, op{opDelete, 1}, op{opDelete, 9},
+		op{opDelete, 8}, op{opDelete, 2}, op{opSearch, 4}, op{opDelete, 100 % keySpace},
+	))
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		ops := decode(b)
+		tr := newTree()
+		m := &model{values: map[int]interface{}{}}
+
+		for i, o := range ops {
+			fail := func(format string, a ...interface{}) {
+				t.Fatalf("%v\nreproduce with %v", fmt.Sprintf(format, a...), reproduce(ops[:i+1]))
+			}
+
+			switch o.kind {
+			case opInsert:
+				m.insert(o.key, i)
+				tr.Insert(o.key, i)
+			case opDelete:
+				m.delete(o.key)
+				tr.Delete(o.key)
+			case opSearch:
+				got, err := tr.Search(o.key)
+				want, ok := m.values[o.key]
+				if ok != (err == nil) || got != want {
+					fail("Search(%v) returned (%v, %v), want %v", o.key, got, err, want)
+				}
+			}
+
+			if err := tr.Validate(); err != nil {
+				fail("%v", err)
+			}
+			if tr.Count() != len(m.keys) {
+				fail("Count() is %v, want %v", tr.Count(), len(m.keys))
+			}
+
+			j := 0
+			tr.Ascend(func(key int, value interface{}) bool {
+				if j >= len(m.keys) || key != m.keys[j] || value != m.values[key] {
+					fail("in-order entry %v is %v/%v, want keys %v", j, key, value, m.keys)
+				}
+				j++
+				return true
+			})
+			if j != len(m.keys) {
+				fail("Ascend visited %v entries, want keys %v", j, m.keys)
+			}
+		}
+	})
+}
+
+// reproduce formats ops as a Go statement list.
+func reproduce(ops []op) string {
+	s := []string{}
+	for i, o := range ops {
+		if o.kind == opInsert {
+			s = append(s, fmt.Sprintf("tree.Insert(%v, %v)", o.key, i))
+		} else {
+			s = append(s, "tree."+o.String())
+		}
+	}
+	return "\n\t" + strings.Join(s, "\n\t")
+}
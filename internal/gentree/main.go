@@ -0,0 +1,83 @@
+/*
+	Command gentree renders the template of the algorithms of a tree for
+	a type of values, so that a specialized tree such as avl.IntTree shares
+	the balancing code of Tree instead of a copy of it.
+
+	It is run by go generate in a tree package:
+
+		gentree -in tree.tmpl -out tree_gen.go -tree Tree -node node -value 'interface{}'
+		gentree -in tree.tmpl -out inttree_gen.go -tree IntTree -node intNode -value int -prefix int
+
+	The template refers to the types by {{.Tree}}, {{.Node}} and {{.Value}},
+	and to package-level names by {{name "height"}}, which is "height" for
+	an empty prefix and "intHeight" for the prefix "int".
+*/
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+func main() {
+	in := flag.String("in", "", "template file")
+	out := flag.String("out", "", "output file")
+	tree := flag.String("tree", "Tree", "name of the tree type")
+	node := flag.String("node", "node", "name of the node type")
+	value := flag.String("value", "interface{}", "type of values")
+	prefix := flag.String("prefix", "", "prefix of package-level names")
+	flag.Parse()
+
+	if err := run(*in, *out, os.Getenv("GOPACKAGE"), data{
+		Tree:   *tree,
+		Node:   *node,
+		Value:  *value,
+		Prefix: *prefix,
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, "gentree:", err)
+		os.Exit(1)
+	}
+}
+
+type data struct {
+	Tree   string
+	Node   string
+	Value  string
+	Prefix string
+}
+
+func run(in, out, pkg string, d data) error {
+	if in == "" || out == "" || pkg == "" {
+		return fmt.Errorf("-in, -out and $GOPACKAGE are required")
+	}
+
+	tmpl, err := template.New(filepath.Base(in)).Funcs(template.FuncMap{
+		"name": func(s string) string {
+			if d.Prefix == "" {
+				return s
+			}
+			return d.Prefix + strings.ToUpper(s[:1]) + s[1:]
+		},
+	}).ParseFiles(in)
+	if err != nil {
+		return err
+	}
+
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "// Code generated by gentree from %v; DO NOT EDIT.\n\npackage %v\n", filepath.Base(in), pkg)
+	if err := tmpl.Execute(b, d); err != nil {
+		return err
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return fmt.Errorf("%v:\n%s", err, b.Bytes())
+	}
+	return os.WriteFile(out, src, 0644)
+}
@@ -0,0 +1,157 @@
+/*
+	Package htmltree renders binary trees as nested HTML lists.
+
+	A tree is drawn by CSS connectors between the items of nested lists.
+	CSS is the only copy of the style sheet: docs/index.html is generated
+	from it by go generate in the llrb directory.
+	Keys and values are escaped by html/template.
+*/
+package htmltree
+
+import (
+	"html/template"
+	"io"
+)
+
+// Node is a node to be rendered.
+type Node struct {
+	Label string
+	Class string // class of the label, e.g. "red" or "black"
+	Title string // tooltip of the label, may be empty
+	// Children are the left and right children, or empty for a leaf.
+	// A nil child is rendered as a hidden placeholder, so that a single
+	// child is drawn on its own side.
+	Children []*Node
+}
+
+// Write writes a <div class="tree"> fragment holding root.
+// root may be nil for an empty tree.
+func Write(w io.Writer, root *Node) error {
+	return templates.ExecuteTemplate(w, "tree", root)
+}
+
+// WritePage writes a standalone HTML page holding root.
+func WritePage(w io.Writer, title string, root *Node) error {
+	return templates.ExecuteTemplate(w, "page", struct {
+		Title string
+		Root  *Node
+	}{title, root})
+}
+
+var templates = template.Must(template.New("").Parse(`
+{{- define "tree"}}<div class="tree">
+{{with .}}<ul>
+{{template "node" .}}</ul>
+{{end}}</div>
+{{end}}
+
+{{- define "node"}}{{if .}}<li>
+<span class="{{.Class}}"{{with .Title}} title="{{.}}"{{end}}>{{.Label}}</span>
+{{with .Children}}<ul>
+{{range .}}{{template "node" .}}{{end}}</ul>
+{{end}}</li>
+{{else}}<li class="empty"></li>
+{{end}}{{end}}
+
+{{- define "page"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>{{.Title}}</title>
+<style>` + CSS + `</style>
+</head>
+<body>
+{{template "tree" .Root}}</body>
+</html>
+{{end}}`))
+
+// CSS is the style sheet of pages written by WritePage and of
+// docs/index.html. Labels are spans classed "red" or "black".
+const CSS = `
+* {margin: 0; padding: 0;}
+
+.tree ul {
+  padding-top: 20px; position: relative;
+  transition: all 0.5s;
+}
+
+.tree li {
+  float: left; text-align: center;
+  list-style-type: none;
+  position: relative;
+  padding: 20px 5px 0 5px;
+  transition: all 0.5s;
+}
+
+/*We will use ::before and ::after to draw the connectors*/
+.tree li::before, .tree li::after {
+  content: '';
+  position: absolute; top: 0; right: 50%;
+  border-top: 1px solid #ccc;
+  width: 50%; height: 20px;
+}
+.tree li::after {
+  right: auto; left: 50%;
+  border-left: 1px solid #ccc;
+}
+
+/*We need to remove left-right connectors from elements without any siblings*/
+.tree li:only-child::after, .tree li:only-child::before {
+  display: none;
+}
+
+/*Remove space from the top of single children*/
+.tree li:only-child { padding-top: 0; }
+
+/*Remove left connector from first child and right connector from last child*/
+.tree li:first-child::before, .tree li:last-child::after {
+  border: 0 none;
+}
+/*Adding back the vertical connector to the last nodes*/
+.tree li:last-child::before {
+  border-right: 1px solid #ccc;
+  border-radius: 0 5px 0 0;
+}
+.tree li:first-child::after {
+  border-radius: 5px 0 0 0;
+}
+
+/*Time to add downward connectors from parents*/
+.tree ul ul::before {
+  content: '';
+  position: absolute; top: 0; left: 50%;
+  border-left: 1px solid #ccc;
+  width: 0; height: 20px;
+}
+
+/*Placeholders of missing children*/
+.tree li.empty { visibility: hidden; }
+
+.tree li span {
+  border: 1px solid #ccc;
+  padding: 5px 10px;
+  text-decoration: none;
+  color: #666;
+  font-family: arial, verdana, tahoma;
+  font-size: 11px;
+  display: inline-block;
+  border-radius: 5px;
+  transition: all 0.5s;
+}
+.tree li span.red {
+  border: 1px solid #ff0000;
+}
+
+/*Time for some hover effects*/
+/*We will apply the hover effect the the lineage of the element also*/
+.tree li span:hover, .tree li span:hover+ul li span {
+  background: #c8e4f8; color: #000; border: 1px solid #94a0b4;
+}
+/*Connector styles on hover*/
+.tree li span:hover+ul li::after,
+.tree li span:hover+ul li::before,
+.tree li span:hover+ul::before,
+.tree li span:hover+ul ul::before {
+  border-color: #94a0b4;
+}
+`
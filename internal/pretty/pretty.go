@@ -0,0 +1,73 @@
+/*
+	Package pretty draws binary trees with box-drawing characters.
+
+	A tree is drawn sideways, one node per line, with the left child above
+	the right one:
+
+		2:200 h=2
+		├── 1:100 h=1
+		└── 3:300 h=1
+*/
+package pretty
+
+import (
+	"io"
+)
+
+// Node is a node to be drawn.
+type Node struct {
+	Label string
+	// Children are the left and right children, or empty for a leaf.
+	// A nil child is drawn as "∅", so that a single child is drawn on
+	// its own side.
+	Children []*Node
+	// More marks a node whose children are cut off by a depth limit.
+	More bool
+}
+
+// Write draws root. root may be nil for an empty tree.
+func Write(w io.Writer, root *Node) error {
+	if root == nil {
+		_, err := io.WriteString(w, "(empty)\n")
+		return err
+	}
+
+	p := &printer{w: w}
+	p.node(root, "", "")
+	return p.err
+}
+
+// printer keeps the first error of writes.
+type printer struct {
+	w   io.Writer
+	err error
+}
+
+func (p *printer) node(n *Node, prefix, indent string) {
+	label := "∅"
+	if n != nil {
+		label = n.Label
+		if n.More {
+			label += " …"
+		}
+	}
+	p.write(prefix + label + "\n")
+
+	if n == nil {
+		return
+	}
+	for i, c := range n.Children {
+		if i < len(n.Children)-1 {
+			p.node(c, indent+"├── ", indent+"│   ")
+		} else {
+			p.node(c, indent+"└── ", indent+"    ")
+		}
+	}
+}
+
+func (p *printer) write(s string) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = io.WriteString(p.w, s)
+}
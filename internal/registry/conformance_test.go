@@ -0,0 +1,320 @@
+package registry_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/masa-suzu/gtree/internal/registry"
+)
+
+// The tests in this file check the behaviour shared by every registered
+// tree. Tests of the invariants of a tree stay in its own package.
+
+type kv struct {
+	k int
+	v interface{}
+}
+
+func TestNewTree(t *testing.T) {
+	for _, impl := range registry.All {
+		want := 0
+		got := impl.New().Count()
+
+		if want != got {
+			t.Errorf("%v: num of nodes must be %v, got %v", impl.Name, want, got)
+		}
+	}
+}
+
+func TestInsert(t *testing.T) {
+
+	tests := []struct {
+		name string
+		want []kv
+	}{
+		{
+			name: "integers",
+			want: []kv{
+				{k: 2, v: 100},
+				{k: 1, v: 200},
+			},
+		},
+		{
+			name: "strings",
+			want: []kv{
+				{k: 1, v: "200"},
+				{k: 2, v: "100"},
+			},
+		},
+		{
+			name: "integers_and_strings",
+			want: []kv{
+				{k: 4, v: 100},
+				{k: 3, v: 200},
+				{k: 1, v: "100"},
+				{k: 2, v: "200"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forAll(t, func(t *testing.T, tree registry.KVS) {
+				for _, kv := range tt.want {
+					tree.Insert(kv.k, kv.v)
+				}
+				assertTree(t, tree, tt.want)
+			})
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name    string
+		deleted []kv
+		want    []kv
+	}{
+		{
+			name: "ascending",
+			deleted: []kv{
+				{k: 1, v: 200},
+				{k: 2, v: 2400},
+				{k: 3, v: 2040},
+			},
+			want: []kv{
+				{k: 4, v: 100},
+				{k: 5, v: 100},
+			},
+		},
+		{
+			name: "descending",
+			deleted: []kv{
+				{k: 5, v: 200},
+				{k: 4, v: 2400},
+				{k: 3, v: 2040},
+			},
+			want: []kv{
+				{k: 2, v: 100},
+				{k: 1, v: 100},
+			},
+		},
+		{
+			name: "random-ordering",
+			deleted: []kv{
+				{k: 6, v: 200},
+				{k: 10, v: 2400},
+				{k: 1, v: 2040},
+				{k: 9, v: 2040},
+				{k: 8, v: 2040},
+				{k: 2, v: 2040},
+			},
+			want: []kv{
+				{k: 4, v: 100},
+				{k: 11, v: 100},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forAll(t, func(t *testing.T, tree registry.KVS) {
+
+				// insert all key-value pairs
+				for _, kv := range tt.deleted {
+					tree.Insert(kv.k, kv.v)
+				}
+
+				for _, kv := range tt.want {
+					tree.Insert(kv.k, kv.v)
+				}
+
+				// delete nodes of tt.delete
+				for _, kv := range tt.deleted {
+					tree.Delete(kv.k)
+				}
+				assertTree(t, tree, tt.want)
+			})
+		})
+	}
+}
+
+func assertTree(t *testing.T, tree registry.KVS, kvs []kv) {
+	t.Helper()
+
+	if len(kvs) != tree.Count() {
+		t.Errorf("num of nodes must be %v, got %v", len(kvs), tree.Count())
+	}
+
+	for _, kv := range kvs {
+		got, err := tree.Search(kv.k)
+
+		if err != nil {
+			t.Errorf("got an error '%v'", err)
+		}
+		if kv.v != got {
+			t.Errorf("want %v, got %v", kv.v, got)
+		}
+	}
+}
+
+func TestInsert_with_SameKeys(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		kvs    []kv
+		unique int
+		want   interface{}
+	}{
+		{
+			name: "Want_Last_Inserted",
+			kvs: []kv{
+				{k: 1, v: 300},
+				{k: 1, v: 100},
+				{k: 1, v: 200},
+			},
+			unique: 1,
+			want:   200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forAll(t, func(t *testing.T, tree registry.KVS) {
+
+				for i := 0; i < len(tt.kvs); i++ {
+					tree.Insert(tt.kvs[i].k, tt.kvs[i].v)
+				}
+
+				if tt.unique != tree.Count() {
+					t.Errorf("num of nodes must be %v, got %v", tt.unique, tree.Count())
+				}
+
+				got, err := tree.Search(tt.kvs[0].k)
+
+				if err != nil {
+					t.Errorf("got an error '%v'", err)
+				}
+				if tt.want != got {
+					t.Errorf("want %v, got %v", tt.want, got)
+				}
+			})
+		})
+	}
+}
+
+func TestSearch_by_InvalidKey(t *testing.T) {
+	forAll(t, func(t *testing.T, tree registry.KVS) {
+		tree.Insert(1, 100)
+
+		got, err := tree.Search(100)
+
+		if err == nil {
+			t.Errorf("got no error")
+		}
+		if got != nil {
+			t.Errorf("got %v, want %v", got, nil)
+		}
+	})
+}
+
+func TestAscend(t *testing.T) {
+	tests := []struct {
+		name     string
+		inserted []kv
+		stop     int
+		want     []kv
+	}{
+		{
+			name:     "empty",
+			inserted: []kv{},
+			want:     []kv{},
+		},
+		{
+			name: "random-ordering",
+			inserted: []kv{
+				{k: 6, v: 600},
+				{k: 10, v: nil},
+				{k: 1, v: 100},
+				{k: 9, v: 900},
+			},
+			want: []kv{
+				{k: 1, v: 100},
+				{k: 6, v: 600},
+				{k: 9, v: 900},
+				{k: 10, v: nil},
+			},
+		},
+		{
+			name: "stop",
+			inserted: []kv{
+				{k: 3, v: 300},
+				{k: 2, v: 200},
+				{k: 1, v: 100},
+			},
+			stop: 2,
+			want: []kv{
+				{k: 1, v: 100},
+				{k: 2, v: 200},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forAll(t, func(t *testing.T, tree registry.KVS) {
+				for _, kv := range tt.inserted {
+					tree.Insert(kv.k, kv.v)
+				}
+
+				got := []kv{}
+				err := ascend(tree, func(key int, value interface{}) bool {
+					got = append(got, kv{k: key, v: value})
+					return key != tt.stop
+				})
+				if err != nil {
+					t.Fatalf("got an error '%v'", err)
+				}
+
+				if len(tt.want) != len(got) {
+					t.Fatalf("num of nodes must be %v, got %v", len(tt.want), len(got))
+				}
+
+				for i, want := range tt.want {
+					if want != got[i] {
+						t.Errorf("want %v, got %v", want, got[i])
+					}
+				}
+			})
+		})
+	}
+}
+
+// forAll runs f on a new tree of every registered implementation.
+func forAll(t *testing.T, f func(t *testing.T, tree registry.KVS)) {
+	t.Helper()
+
+	for _, impl := range registry.All {
+		impl := impl
+		t.Run(impl.Name, func(t *testing.T) {
+			f(t, impl.New())
+		})
+	}
+}
+
+// ascend calls the Ascend method of tree, which returns an error for
+// the disk-backed trees.
+func ascend(tree registry.KVS, f func(key int, value interface{}) bool) error {
+	switch tree := tree.(type) {
+	case interface {
+		Ascend(f func(key int, value interface{}) bool)
+	}:
+		tree.Ascend(f)
+		return nil
+	case interface {
+		Ascend(f func(key int, value interface{}) bool) error
+	}:
+		return tree.Ascend(f)
+	}
+	return fmt.Errorf("%T has no Ascend", tree)
+}
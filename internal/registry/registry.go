@@ -0,0 +1,71 @@
+/*
+	Package registry lists the trees of gtree for the commands measuring
+	or replaying operations on them.
+*/
+package registry
+
+import (
+	"math/rand"
+
+	"github.com/masa-suzu/gtree/aa"
+	"github.com/masa-suzu/gtree/avl"
+	"github.com/masa-suzu/gtree/btree"
+	"github.com/masa-suzu/gtree/llrb"
+	"github.com/masa-suzu/gtree/rbtree"
+	"github.com/masa-suzu/gtree/scapegoat"
+	"github.com/masa-suzu/gtree/splay"
+	"github.com/masa-suzu/gtree/treap"
+	"github.com/masa-suzu/gtree/veb"
+	"github.com/masa-suzu/gtree/wavl"
+)
+
+// KVS is the interface of registered trees.
+// It has the same methods as the kvs interface of the benchmark package.
+type KVS interface {
+	Search(key int) (interface{}, error)
+	Insert(key int, value interface{})
+	Delete(key int)
+	Count() int
+}
+
+// Implementation is a registered tree.
+type Implementation struct {
+	Name string
+	New  func() KVS
+	// InRange reports whether the tree can hold a key.
+	// It is nil for trees holding every int.
+	InRange func(key int) bool
+}
+
+// All holds every registered tree.
+// Add a new implementation here to include it in the commands.
+var All = []Implementation{
+	{Name: "avl", New: func() KVS { return avl.New() }},
+	{Name: "llrb", New: func() KVS { return llrb.New() }},
+	{Name: "avl-arena", New: func() KVS {
+		tree, _ := avl.NewWithArena(1024)
+		return tree
+	}},
+	{Name: "llrb-arena", New: func() KVS {
+		tree, _ := llrb.NewWithArena(1024)
+		return tree
+	}},
+	{Name: "rbtree", New: func() KVS { return rbtree.New() }},
+	{Name: "wavl", New: func() KVS { return wavl.New() }},
+	{Name: "aa", New: func() KVS { return aa.New() }},
+	{Name: "scapegoat", New: func() KVS { return scapegoat.New() }},
+	{Name: "treap", New: func() KVS { return treap.NewWithSource(rand.NewSource(1)) }},
+	{Name: "splay", New: func() KVS { return splay.New() }},
+	{Name: "veb", New: func() KVS { return veb.New() }, InRange: veb.New().InRange},
+	{Name: "btree", New: func() KVS { return btree.New() }},
+}
+
+// Find returns the implementation of a given name.
+func Find(name string) (Implementation, bool) {
+	for _, impl := range All {
+		if impl.Name == name {
+			return impl, true
+		}
+	}
+	return Implementation{}, false
+}
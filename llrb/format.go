@@ -0,0 +1,65 @@
+package llrb
+
+import (
+	"fmt"
+
+	"github.com/masa-suzu/gtree/internal/pretty"
+)
+
+// String returns the entries in ascending order of keys, like "[1:100 2:200]".
+func (t *Tree) String() string {
+	return fmt.Sprint(t)
+}
+
+// Format implements fmt.Formatter.
+//
+// %v and %s print the entries in ascending order of keys.
+// %+v draws the structure of the tree sideways, one node per line, with
+// the color of each node. A precision limits the depth of drawn nodes,
+// e.g. %+.3v draws the nodes up to depth 3 and marks cut off subtrees
+// with "…".
+func (t *Tree) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		depth, ok := s.Precision()
+		if !ok {
+			depth = -1
+		}
+		pretty.Write(s, prettyNode(t.root, depth))
+	case verb == 'v' || verb == 's':
+		fmt.Fprint(s, "[")
+		sep := ""
+		t.Ascend(func(key int, value interface{}) bool {
+			fmt.Fprintf(s, "%v%v:%v", sep, key, value)
+			sep = " "
+			return true
+		})
+		fmt.Fprint(s, "]")
+	default:
+		fmt.Fprintf(s, "%%!%c(*llrb.Tree=%v)", verb, t)
+	}
+}
+
+// prettyNode returns the view of n down to depth, or of the whole subtree
+// if depth is negative.
+func prettyNode(n *node, depth int) *pretty.Node {
+	if n == nil {
+		return nil
+	}
+
+	color := "black"
+	if n.isRed() {
+		color = "red"
+	}
+	v := &pretty.Node{Label: fmt.Sprintf("%v:%v %v", n.key, n.value, color)}
+
+	if n.left == nil && n.right == nil {
+		return v
+	}
+	if depth == 0 {
+		v.More = true
+		return v
+	}
+	v.Children = []*pretty.Node{prettyNode(n.left, depth-1), prettyNode(n.right, depth-1)}
+	return v
+}
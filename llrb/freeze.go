@@ -0,0 +1,22 @@
+package llrb
+
+import (
+	"github.com/masa-suzu/gtree/frozen"
+)
+
+// Freeze returns an immutable copy of the tree laid out in an array for
+// reads, which is faster to search than the tree for trees built once.
+// Values are shared with the tree, which can still be modified.
+func (t *Tree) Freeze() *frozen.Index {
+	keys := make([]int, 0, t.count)
+	values := make([]interface{}, 0, t.count)
+	t.Ascend(func(key int, value interface{}) bool {
+		keys = append(keys, key)
+		values = append(values, value)
+		return true
+	})
+
+	// keys of a tree are in ascending order
+	x, _ := frozen.New(keys, values)
+	return x
+}
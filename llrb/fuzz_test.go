@@ -0,0 +1,12 @@
+package llrb_test
+
+import (
+	"testing"
+
+	"github.com/masa-suzu/gtree/internal/fuzztest"
+	"github.com/masa-suzu/gtree/llrb"
+)
+
+func FuzzDifferential(f *testing.F) {
+	fuzztest.Run(f, func() fuzztest.Tree { return llrb.New() })
+}
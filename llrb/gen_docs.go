@@ -0,0 +1,36 @@
+//go:build ignore
+// +build ignore
+
+// gen_docs writes docs/index.html, a sample page drawn by WriteHTMLPage.
+// Run it by go generate in the llrb directory.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/masa-suzu/gtree/llrb"
+)
+
+func main() {
+	out := flag.String("o", "../docs/index.html", "output file")
+	flag.Parse()
+
+	tree := llrb.New()
+	for _, k := range []int{10, 20, 30, 40, 50, 25} {
+		tree.Insert(k, 600)
+	}
+	for k := 100; k <= 115; k++ {
+		tree.Insert(k, (k-100)*20)
+	}
+
+	b := &bytes.Buffer{}
+	if err := tree.WriteHTMLPage(b, "llrb"); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, b.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,94 @@
+package llrb
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/masa-suzu/gtree/internal/htmltree"
+)
+
+//go:generate go run gen_docs.go -o ../docs/index.html
+
+// WriteHTML writes the tree as nested lists in a <div class="tree">,
+// which is styled by htmltree.CSS, the style sheet of WriteHTMLPage.
+// Each node is labeled "key/value" in a span classed "red" or "black".
+// Keys and values are escaped.
+func (t *Tree) WriteHTML(w io.Writer) error {
+	return htmltree.Write(w, t.root.view())
+}
+
+// WriteHTMLPage writes a standalone HTML page drawing the tree.
+func (t *Tree) WriteHTMLPage(w io.Writer, title string) error {
+	return htmltree.WritePage(w, title, t.root.view())
+}
+
+// ToHTML writes the tree as nested lists of <red> and <black> elements,
+// ignoring errors. Keys and values are not escaped.
+//
+// Deprecated: Use WriteHTML.
+func (t *Tree) ToHTML(w io.Writer) {
+	w.Write([]byte("<div class=\"tree\">\n"))
+	if t.root != nil {
+		t.root.ToHTML(w)
+	}
+	w.Write([]byte("</div>\n"))
+}
+
+func (n *node) ToHTML(w io.Writer) {
+	indent(w, []byte("<ul>\n"), 1)
+	n.toHTML(w, 1)
+	indent(w, []byte("</ul>\n"), 1)
+}
+
+func (n *node) toHTML(w io.Writer, numOfIndents int) {
+
+	indent(w, []byte("<li>\n"), numOfIndents+1)
+
+	if n.color {
+		indent(w, []byte(fmt.Sprintf("<red href=\"#\">%v/%v</red>\n", n.key, n.value)), numOfIndents+2)
+	} else {
+		indent(w, []byte(fmt.Sprintf("<black href=\"#\">%v/%v</black>\n", n.key, n.value)), numOfIndents+2)
+	}
+
+	if n.left != nil || n.right != nil {
+		indent(w, []byte("<ul>\n"), numOfIndents+2)
+	}
+
+	if n.left != nil {
+		n.left.toHTML(w, numOfIndents+2)
+	}
+	if n.right != nil {
+		n.right.toHTML(w, numOfIndents+2)
+	}
+
+	if n.left != nil || n.right != nil {
+		indent(w, []byte("</ul>\n"), numOfIndents+2)
+	}
+
+	indent(w, []byte("</li>\n"), numOfIndents+1)
+}
+
+func indent(w io.Writer, v []byte, indents int) {
+	for index := 0; index < indents; index++ {
+		w.Write([]byte("  "))
+	}
+	w.Write(v)
+}
+
+func (n *node) view() *htmltree.Node {
+	if n == nil {
+		return nil
+	}
+
+	v := &htmltree.Node{
+		Label: fmt.Sprintf("%v/%v", n.key, n.value),
+		Class: "black",
+	}
+	if n.isRed() {
+		v.Class = "red"
+	}
+	if n.left != nil || n.right != nil {
+		v.Children = []*htmltree.Node{n.left.view(), n.right.view()}
+	}
+	return v
+}
@@ -0,0 +1,104 @@
+package llrb
+
+import (
+	"fmt"
+)
+
+// IntTree implements a LLRB tree mapping int keys to int values.
+// Values are stored inline in nodes instead of as interface{}, so Search
+// never allocates and Insert allocates only the node of a new key.
+// It is generated from the same template as Tree, but has no recorder,
+// observer, arena or parent pointers.
+type IntTree struct {
+	root  *intNode
+	count int
+}
+
+type intNode struct {
+	key   int
+	value int
+	left  *intNode
+	right *intNode
+	color bool
+}
+
+// NewInt returns a reference to an empty IntTree.
+func NewInt() *IntTree {
+	return &IntTree{}
+}
+
+// Count returns num of nodes.
+func (t *IntTree) Count() int {
+	return t.count
+}
+
+// Search returns a value associated with a given key.
+// If no value is found by the key, returns false.
+func (t *IntTree) Search(key int) (int, bool) {
+	if n := t.find(key); n != nil {
+		return n.value, true
+	}
+	return 0, false
+}
+
+// Insert a value with a given key.
+// If the same key has already inserted, the new value overrides old one.
+func (t *IntTree) Insert(key, value int) {
+	t.root, _, _ = t.insert(t.root, key, value)
+	t.root.color = black
+}
+
+// Delete remove a node by a given key.
+// If the key does not found, do nothing.
+func (t *IntTree) Delete(key int) {
+	t.root, _, _ = t.delete(t.root, key)
+	if t.root != nil {
+		t.root.color = black
+	}
+}
+
+// Ascend calls f for each key-value pair in ascending order of keys.
+// If f returns false, Ascend stops the iteration.
+func (t *IntTree) Ascend(f func(key, value int) bool) {
+	intAscend(t.root, f)
+}
+
+// Validate checks the invariants of the tree as Tree.Validate does.
+func (t *IntTree) Validate() error {
+	if t.root.isRed() {
+		return &InvariantError{Key: t.root.key, Reason: "root must be black"}
+	}
+
+	n, _, err := intValidate(t.root, nil, "", nil, nil)
+	if err != nil {
+		return err
+	}
+	if n != t.count {
+		return fmt.Errorf("llrb: Count() is %v, but the tree has %v nodes", t.count, n)
+	}
+	return nil
+}
+
+// The hooks of the template do nothing for IntTree.
+
+func (t *IntTree) newNode() *intNode {
+	return &intNode{}
+}
+
+func (t *IntTree) release(n *intNode) {}
+
+func (t *IntTree) record(kind EventKind, n *intNode) {}
+
+func (t *IntTree) rotated(kind EventKind, n *intNode) {}
+
+func (t *IntTree) flipped(n *intNode) {}
+
+func (n *intNode) parent() *intNode {
+	return nil
+}
+
+func (n *intNode) setParent(p *intNode) {}
+
+func (n *intNode) linked(p *intNode) bool {
+	return true
+}
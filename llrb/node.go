@@ -1,16 +1,12 @@
 package llrb
 
-import (
-	"fmt"
-	"io"
-)
-
 const (
 	red   = true
 	black = false
 )
 
 type node struct {
+	up    link // first, as it is empty without the gtree_parent tag
 	key   int
 	value interface{}
 	left  *node
@@ -18,57 +14,33 @@ type node struct {
 	color bool
 }
 
-func (n *node) isRed() bool {
-	if n == nil {
-		return false
-	}
-	return n.color
-}
-
-func (n *node) isBlack() bool {
-	if n == nil {
-		return false
-	}
-	return !n.color
+// arena allocates nodes from chunks and recycles deleted nodes.
+type arena struct {
+	size  int
+	chunk []node
+	free  *node // linked by left
 }
 
-func (n *node) ToHTML(w io.Writer) {
-	indent(w, []byte("<ul>\n"), 1)
-	n.toHTML(w, 1)
-	indent(w, []byte("</ul>\n"), 1)
+func newArena(size int) *arena {
+	return &arena{size: size}
 }
 
-func (n *node) toHTML(w io.Writer, numOfIndents int) {
-
-	indent(w, []byte("<li>\n"), numOfIndents+1)
-
-	if n.color {
-		indent(w, []byte(fmt.Sprintf("<red href=\"#\">%v/%v</red>\n", n.key, n.value)), numOfIndents+2)
-	} else {
-		indent(w, []byte(fmt.Sprintf("<black href=\"#\">%v/%v</black>\n", n.key, n.value)), numOfIndents+2)
+func (a *arena) alloc() *node {
+	if n := a.free; n != nil {
+		a.free = n.left
+		n.left = nil
+		return n
 	}
-
-	if n.left != nil || n.right != nil {
-		indent(w, []byte("<ul>\n"), numOfIndents+2)
-	}
-
-	if n.left != nil {
-		n.left.toHTML(w, numOfIndents+2)
-	}
-	if n.right != nil {
-		n.right.toHTML(w, numOfIndents+2)
+	if len(a.chunk) == 0 {
+		a.chunk = make([]node, a.size)
 	}
-
-	if n.left != nil || n.right != nil {
-		indent(w, []byte("</ul>\n"), numOfIndents+2)
-	}
-
-	indent(w, []byte("</li>\n"), numOfIndents+1)
+	n := &a.chunk[0]
+	a.chunk = a.chunk[1:]
+	return n
 }
 
-func indent(w io.Writer, v []byte, indents int) {
-	for index := 0; index < indents; index++ {
-		w.Write([]byte("  "))
-	}
-	w.Write(v)
+// release recycles n, clearing its value for the GC.
+func (a *arena) release(n *node) {
+	*n = node{left: a.free}
+	a.free = n
 }
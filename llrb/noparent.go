@@ -0,0 +1,54 @@
+//go:build !gtree_parent
+// +build !gtree_parent
+
+package llrb
+
+// link is empty by default, so that nodes carry no parent pointer.
+// Build with the gtree_parent tag to keep one, see parent.go.
+type link struct{}
+
+// hasParent reports whether nodes link to their parents.
+const hasParent = false
+
+func (n *node) parent() *node {
+	return nil
+}
+
+func (n *node) setParent(p *node) {}
+
+// linked reports true, as there is no parent to check.
+func (n *node) linked(p *node) bool {
+	return true
+}
+
+// maxDepth bounds num of ancestors of a node, as a LLRB tree of 1<<63 nodes is at most 126 high.
+const maxDepth = 128
+
+// ancestors is the path from the root to the node a cursor points at.
+// It is an array rather than a slice, so that a Cursor does not allocate.
+type ancestors struct {
+	nodes [maxDepth]*node
+	n     int
+}
+
+func (a *ancestors) push(n *node) {
+	a.nodes[a.n] = n
+	a.n++
+}
+
+// pop returns the parent of n, which is the node the cursor points at.
+func (a *ancestors) pop(n *node) *node {
+	if a.n == 0 {
+		return nil
+	}
+	a.n--
+	return a.nodes[a.n]
+}
+
+func (a *ancestors) depth() int {
+	return a.n
+}
+
+func (a *ancestors) truncate(d int) {
+	a.n = d
+}
@@ -0,0 +1,25 @@
+package llrb
+
+// Observer is notified of mutations of a Tree.
+// Callbacks run synchronously in the goroutine mutating the tree.
+type Observer interface {
+	// OnInsert is called after Insert, where new reports whether the key
+	// was absent.
+	OnInsert(key int, new bool)
+	// OnUpdate is called when Insert replaces the value of a present key,
+	// before OnInsert.
+	OnUpdate(key int, old, value interface{})
+	// OnDelete is called after Delete removes a present key.
+	OnDelete(key int, value interface{})
+	// OnRotate is called on each rotation, where kind is RotateLeft or
+	// RotateRight and pivot is the key of the new root of the subtree.
+	// The tree is being rebalanced and must not be accessed.
+	OnRotate(kind EventKind, pivot int)
+}
+
+// SetObserver makes the tree notify o of mutations.
+// If o is nil, the tree stops notifying. A tree without an observer pays
+// only a nil check per operation and rotation.
+func (t *Tree) SetObserver(o Observer) {
+	t.obs = o
+}
@@ -0,0 +1,43 @@
+//go:build gtree_parent
+// +build gtree_parent
+
+package llrb
+
+// link is the parent pointer of a node. It is kept only with the
+// gtree_parent build tag, which costs a pointer per node and makes
+// a Cursor move by parents instead of carrying a stack of ancestors.
+type link struct {
+	p *node
+}
+
+// hasParent reports whether nodes link to their parents.
+const hasParent = true
+
+func (n *node) parent() *node {
+	return n.up.p
+}
+
+func (n *node) setParent(p *node) {
+	n.up.p = p
+}
+
+// linked reports whether p is the parent of n.
+func (n *node) linked(p *node) bool {
+	return n.up.p == p
+}
+
+// ancestors is empty, as a cursor finds them by parent pointers.
+type ancestors struct{}
+
+func (a *ancestors) push(n *node) {}
+
+// pop returns the parent of n, which is the node the cursor points at.
+func (a *ancestors) pop(n *node) *node {
+	return n.parent()
+}
+
+func (a *ancestors) depth() int {
+	return 0
+}
+
+func (a *ancestors) truncate(d int) {}
@@ -0,0 +1,72 @@
+package llrb
+
+// Stats describes the shape of a Tree.
+type Stats struct {
+	Count int
+	// Height is num of nodes on the longest path from the root,
+	// or 0 for an empty tree.
+	Height int
+	// BlackHeight is num of black nodes on every path from the root to
+	// a leaf.
+	BlackHeight int
+	// MinLeafDepth, MaxLeafDepth and AvgLeafDepth are the depths of leaves,
+	// where the root is at depth 0.
+	MinLeafDepth int
+	MaxLeafDepth int
+	AvgLeafDepth float64
+	// Depths[d] is num of nodes at depth d.
+	Depths []int
+	// Rotations and Flips are num of rotations and color flips since New.
+	Rotations int
+	Flips     int
+}
+
+// Stats returns the statistics of the tree.
+// It walks every node, so it takes O(n) time.
+func (t *Tree) Stats() Stats {
+	s := Stats{
+		Count:     t.count,
+		Depths:    []int{},
+		Rotations: t.rotations,
+		Flips:     t.flips,
+	}
+
+	for n := t.root; n != nil; n = n.left {
+		if !n.isRed() {
+			s.BlackHeight++
+		}
+	}
+
+	leaves, sum := 0, 0
+	var walk func(n *node, d int)
+	walk = func(n *node, d int) {
+		if n == nil {
+			return
+		}
+		if d == len(s.Depths) {
+			s.Depths = append(s.Depths, 0)
+		}
+		s.Depths[d]++
+
+		if n.left == nil && n.right == nil {
+			if leaves == 0 || d < s.MinLeafDepth {
+				s.MinLeafDepth = d
+			}
+			if d > s.MaxLeafDepth {
+				s.MaxLeafDepth = d
+			}
+			leaves++
+			sum += d
+			return
+		}
+		walk(n.left, d+1)
+		walk(n.right, d+1)
+	}
+	walk(t.root, 0)
+
+	s.Height = len(s.Depths)
+	if leaves > 0 {
+		s.AvgLeafDepth = float64(sum) / float64(leaves)
+	}
+	return s
+}
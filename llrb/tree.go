@@ -1,14 +1,22 @@
 /*
 	Package llrb provides an implementation of Left-Leaning Red-Black Tree.
 	Original implementation is available from http://www.cs.princeton.edu/~rs/talks/LLRB/LLRB.pdf.
+
+	Nodes keep no parent pointers by default, so a Cursor keeps the
+	ancestors of its entry in an explicit stack: a fixed array of 128
+	nodes, enough for any LLRB tree of less than 1<<63 nodes, which makes
+	a Cursor about 1 KiB. With the gtree_parent build tag, every node keeps
+	a pointer to its parent instead, and a Cursor holds only its node.
 */
 package llrb
 
 import (
 	"fmt"
-	"io"
 )
 
+//go:generate go run ../internal/gentree -in tree.tmpl -out tree_gen.go -tree Tree -node node -value interface{}
+//go:generate go run ../internal/gentree -in tree.tmpl -out inttree_gen.go -tree IntTree -node intNode -value int -prefix int
+
 const (
 	lt = -1
 	eq = 0
@@ -19,6 +27,12 @@ const (
 type Tree struct {
 	root  *node
 	count int
+	rec   *Recorder
+	obs   Observer
+
+	rotations int
+	flips     int
+	arena     *arena
 }
 
 // New returns a reference to an empty Tree.
@@ -29,6 +43,27 @@ func New() *Tree {
 	}
 }
 
+// NewWithArena returns a reference to an empty Tree allocating nodes
+// from chunks of chunkSize nodes and recycling deleted nodes, which cuts
+// allocations and the work of the GC for trees under churn.
+// A chunk is kept alive as long as any of its nodes is in the tree.
+func NewWithArena(chunkSize int) (*Tree, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("chunk size must be positive, got %v", chunkSize)
+	}
+	return &Tree{arena: newArena(chunkSize)}, nil
+}
+
+// Reset removes all nodes at once.
+// A tree with an arena drops its chunks and allocates new ones.
+func (t *Tree) Reset() {
+	t.root = nil
+	t.count = 0
+	if t.arena != nil {
+		t.arena = newArena(t.arena.size)
+	}
+}
+
 // Count returns num of nodes.
 func (t *Tree) Count() int {
 	return t.count
@@ -37,18 +72,8 @@ func (t *Tree) Count() int {
 // Search returns a value associated with a given key.
 // If no value is found by the key, returns nil with an error.
 func (t *Tree) Search(key int) (interface{}, error) {
-	x := t.root
-
-	for x != nil {
-		cmp := compare(key, x.key)
-		switch cmp {
-		case eq:
-			return x.value, nil
-		case lt:
-			x = x.left
-		case gt:
-			x = x.right
-		}
+	if n := t.find(key); n != nil {
+		return n.value, nil
 	}
 	return nil, fmt.Errorf("found no value by key '%v'", key)
 }
@@ -56,168 +81,69 @@ func (t *Tree) Search(key int) (interface{}, error) {
 // Insert a value with a given key.
 // If the same key has already inserted, the new value overrides old one.
 func (t *Tree) Insert(key int, value interface{}) {
-	t.root = t.insert(t.root, key, value)
-
-	if t.root == nil {
-		return
-	}
-
+	var old interface{}
+	var present bool
+	t.root, old, present = t.insert(t.root, key, value)
 	t.root.color = black
-}
+	t.root.setParent(nil)
 
-func (t *Tree) insert(n *node, key int, value interface{}) *node {
-	if n == nil {
-		t.count = t.count + 1
-		return &node{
-			key:   key,
-			value: value,
-			color: red,
-			left:  nil,
-			right: nil,
-		}
+	if t.obs == nil {
+		return
 	}
-
-	cmp := compare(key, n.key)
-
-	switch cmp {
-	case eq:
-		n.value = value
-	case lt:
-		n.left = t.insert(n.left, key, value)
-	case gt:
-		n.right = t.insert(n.right, key, value)
+	if present {
+		t.obs.OnUpdate(key, old, value)
 	}
-	return fixup(n)
+	t.obs.OnInsert(key, !present)
 }
 
 // Delete remove a node by a given key.
 // If the key does not found, do nothing.
 func (t *Tree) Delete(key int) {
-	t.root = t.delete(t.root, key)
-	if t.root == nil {
-		return
-	}
-	t.root.color = black
-}
-
-func (t *Tree) delete(n *node, key int) *node {
-	if n == nil {
-		return nil
-	}
-
-	if compare(key, n.key) == lt {
-		if n.left.isBlack() && !n.left.left.isRed() {
-			n = moveRedLeft(n)
-		}
-		n.left = t.delete(n.left, key)
-	} else {
-		if n.left.isRed() {
-			n = rotateRight(n)
-		}
-		if n.right.isBlack() && !n.right.left.isRed() {
-			n = moveRedRight(n)
-		}
-
-		if compare(key, n.key) == eq {
-			t.count = t.count - 1
-
-			if n.right == nil {
-				return nil
-			}
-
-			rm := min(n.right)
-			n.key = rm.key
-			n.value = rm.value
-			n.right = deleteMin(n.right)
-
-		} else {
-			n.right = t.delete(n.right, key)
-		}
-	}
-	return fixup(n)
-}
-
-func deleteMin(n *node) *node {
-	if n.left == nil {
-		return nil
+	var old interface{}
+	var present bool
+	t.root, old, present = t.delete(t.root, key)
+	if t.root != nil {
+		t.root.color = black
+		t.root.setParent(nil)
 	}
 
-	if n.left.isBlack() && !n.left.left.isRed() {
-		n = moveRedLeft(n)
+	if t.obs != nil && present {
+		t.obs.OnDelete(key, old)
 	}
-	n.left = deleteMin(n.left)
-	return fixup(n)
 }
 
-func (t *Tree) ToHTML(w io.Writer) {
-	w.Write([]byte("<div class=\"tree\">\n"))
-	if t.root != nil {
-		t.root.ToHTML(w)
+func (t *Tree) newNode() *node {
+	if t.arena != nil {
+		return t.arena.alloc()
 	}
-	w.Write([]byte("</div>\n"))
+	return &node{}
 }
 
-func fixup(n *node) *node {
-	if n.right.isRed() {
-		n = rotateLeft(n)
-	}
-	if n.left.isRed() && n.left.left.isRed() {
-		n = rotateRight(n)
+func (t *Tree) release(n *node) {
+	if t.arena != nil {
+		t.arena.release(n)
 	}
-	if n.left.isRed() && n.right.isRed() {
-		flip(n)
-	}
-	return n
-}
-
-func flip(n *node) {
-	n.color = !n.color
-	n.left.color = !n.left.color
-	n.right.color = !n.right.color
 }
 
-func rotateLeft(n *node) *node {
-	var x = n.right
-	n.right = x.left
-	x.left = n
-	x.color = n.color
-	n.color = red
-	return x
-}
-
-func rotateRight(n *node) *node {
-	var x = n.left
-	n.left = x.right
-	x.right = n
-	x.color = n.color
-	n.color = red
-	return x
-}
-
-func moveRedLeft(n *node) *node {
-	flip(n)
-	if n.right.left.isRed() {
-		n.right = rotateRight(n.right)
-		n = rotateLeft(n)
-		flip(n)
+// rotated counts a rotation to n and notifies the recorder and the observer.
+func (t *Tree) rotated(kind EventKind, n *node) {
+	t.rotations++
+	t.record(kind, n)
+	if t.obs != nil {
+		t.obs.OnRotate(kind, n.key)
 	}
-	return n
 }
 
-func moveRedRight(n *node) *node {
-	flip(n)
-	if n.left.left.isRed() {
-		n = rotateRight(n)
-		flip(n)
-	}
-	return n
+// flipped counts a color flip of n and notifies the recorder.
+func (t *Tree) flipped(n *node) {
+	t.flips++
+	t.record(Flip, n)
 }
 
-func min(n *node) *node {
-	for n.left != nil {
-		n = n.left
-	}
-	return n
+// Ascend calls f for each key-value pair in ascending order of keys.
+// If f returns false, Ascend stops the iteration.
+func (t *Tree) Ascend(f func(key int, value interface{}) bool) {
+	ascend(t.root, f)
 }
 
 func compare(k1, k2 int) int {
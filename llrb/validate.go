@@ -0,0 +1,41 @@
+package llrb
+
+import (
+	"fmt"
+)
+
+// InvariantError describes the first node which breaks an invariant.
+type InvariantError struct {
+	// Key is the key of the node.
+	Key int
+	// Path is the way from the root to the node,
+	// where 'L' is a left child and 'R' is a right child.
+	Path string
+	// Reason is the broken invariant.
+	Reason string
+}
+
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("llrb: key '%v' at path '%v': %v", e.Key, e.Path, e.Reason)
+}
+
+// Validate checks the invariants of the tree: keys are in order, Count
+// matches num of nodes, the root is black, no red node leans right, no red
+// node has a red child, every path from the root to a leaf has the same
+// num of black nodes, and parent pointers, if kept by the gtree_parent
+// build tag, link children to their parents.
+// It returns an *InvariantError for the first violation in preorder.
+func (t *Tree) Validate() error {
+	if t.root.isRed() {
+		return &InvariantError{Key: t.root.key, Reason: "root must be black"}
+	}
+
+	n, _, err := validate(t.root, nil, "", nil, nil)
+	if err != nil {
+		return err
+	}
+	if n != t.count {
+		return fmt.Errorf("llrb: Count() is %v, but the tree has %v nodes", t.count, n)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package llrb
+
+import (
+	"testing"
+)
+
+func TestValidate_Broken(t *testing.T) {
+	tests := []struct {
+		name string
+		tree func() *Tree
+		key  int
+		path string
+	}{
+		{
+			name: "red-root",
+			tree: func() *Tree {
+				tree := New()
+				tree.Insert(1, nil)
+				tree.root.color = red
+				return tree
+			},
+			key:  1,
+			path: "",
+		},
+		{
+			name: "right-leaning-red",
+			tree: func() *Tree {
+				tree := New()
+				tree.Insert(1, nil)
+				tree.root.right = &node{key: 2, color: red}
+				tree.count++
+				return tree
+			},
+			key:  1,
+			path: "",
+		},
+		{
+			name: "two-reds",
+			tree: func() *Tree {
+				tree := New()
+				tree.Insert(3, nil)
+				tree.Insert(2, nil)
+				tree.root.left.left = &node{key: 1, color: red}
+				tree.count++
+				return tree
+			},
+			key:  2,
+			path: "L",
+		},
+		{
+			name: "black-height",
+			tree: func() *Tree {
+				tree := New()
+				tree.Insert(2, nil)
+				tree.Insert(1, nil)
+				tree.root.left.color = black
+				return tree
+			},
+			key:  2,
+			path: "",
+		},
+		{
+			name: "order",
+			tree: func() *Tree {
+				tree := New()
+				tree.Insert(2, nil)
+				tree.Insert(1, nil)
+				tree.root.left.key = 3
+				return tree
+			},
+			key:  3,
+			path: "L",
+		},
+		{
+			name: "parent",
+			tree: func() *Tree {
+				tree := New()
+				tree.Insert(2, nil)
+				tree.Insert(1, nil)
+				tree.Insert(3, nil)
+				tree.root.right.setParent(tree.root.left)
+				return tree
+			},
+			key:  3,
+			path: "R",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.name == "parent" && !hasParent {
+				t.Skip("nodes have no parent without the gtree_parent tag")
+			}
+			err := tt.tree().Validate()
+
+			e, ok := err.(*InvariantError)
+			if !ok {
+				t.Fatalf("want an *InvariantError, got '%v'", err)
+			}
+			if e.Key != tt.key || e.Path != tt.path {
+				t.Errorf("want key %v at '%v', got '%v'", tt.key, tt.path, e)
+			}
+		})
+	}
+}
+
+func TestValidate_Count(t *testing.T) {
+	tree := New()
+	tree.Insert(1, nil)
+	tree.count = 2
+
+	if err := tree.Validate(); err == nil {
+		t.Errorf("got no error for a wrong count")
+	}
+}
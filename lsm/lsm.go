@@ -0,0 +1,467 @@
+/*
+	Package lsm provides a log-structured merge storage engine.
+
+	Writes are buffered in an llrb.Tree (the memtable). When the memtable
+	exceeds Options.MemtableSize, it is flushed to an immutable sorted file
+	at level 0. Deletes are recorded as tombstones. Once level 0 holds
+	Options.L0Tables tables, a background compaction merges them with the
+	level 1 table into a new level 1 table, dropping shadowed values and
+	tombstones.
+
+	The memtable is not logged, so writes which have not been flushed are
+	lost on a crash. Call Flush or Close to persist them.
+*/
+package lsm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/masa-suzu/gtree/llrb"
+)
+
+// ErrClosed is returned by operations on a closed DB.
+var ErrClosed = errors.New("lsm: db is closed")
+
+// Options configures a DB.
+type Options struct {
+	// MemtableSize is the num of entries which triggers a flush.
+	MemtableSize int
+	// L0Tables is the num of level 0 tables which triggers a compaction.
+	L0Tables int
+}
+
+// DefaultOptions is used when Open is called with nil options.
+var DefaultOptions = Options{
+	MemtableSize: 4096,
+	L0Tables:     4,
+}
+
+// tombstone marks a deleted key in the memtable.
+type tombstone struct{}
+
+// DB is an LSM-tree key-value store on a local directory.
+type DB struct {
+	mu     sync.RWMutex
+	dir    string
+	opts   Options
+	mem    *llrb.Tree
+	l0     []*table // ordered from oldest to newest
+	l1     *table
+	seq    int
+	closed bool
+
+	compacting bool
+	done       *sync.Cond // signaled when a compaction finishes
+	err        error      // error of a compaction not reported yet
+}
+
+// Open opens the DB in dir, creating dir if needed.
+func Open(dir string, opts *Options) (*DB, error) {
+	if opts == nil {
+		opts = &DefaultOptions
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	db := &DB{
+		dir:  dir,
+		opts: *opts,
+		mem:  llrb.New(),
+	}
+	db.done = sync.NewCond(&db.mu)
+	if err := db.load(); err != nil {
+		db.closeTables()
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *DB) load() error {
+	paths, err := filepath.Glob(filepath.Join(db.dir, "*.sst"))
+	if err != nil {
+		return err
+	}
+
+	tables := []*table{}
+	for _, path := range paths {
+		var seq, level int
+		if _, err := fmt.Sscanf(filepath.Base(path), "%06d.L%d.sst", &seq, &level); err != nil {
+			continue
+		}
+		t, err := openTable(path, seq, level)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, t)
+		if seq > db.seq {
+			db.seq = seq
+		}
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].seq < tables[j].seq })
+
+	for _, t := range tables {
+		if t.level == 1 && (db.l1 == nil || t.seq > db.l1.seq) {
+			db.l1 = t
+		}
+	}
+
+	// Tables older than the level 1 table were inputs of a compaction
+	// which was interrupted before it removed them.
+	for _, t := range tables {
+		switch {
+		case t == db.l1:
+		case db.l1 != nil && t.seq < db.l1.seq:
+			t.close()
+			os.Remove(t.path)
+		default:
+			db.l0 = append(db.l0, t)
+		}
+	}
+	return nil
+}
+
+// Search returns a value associated with a given key.
+// If no value is found by the key, returns nil with an error.
+func (db *DB) Search(key int) (interface{}, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return nil, ErrClosed
+	}
+
+	if v, err := db.mem.Search(key); err == nil {
+		if _, ok := v.(tombstone); ok {
+			return nil, notFound(key)
+		}
+		return v, nil
+	}
+
+	for _, t := range db.tables() {
+		e, ok, err := t.search(key)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if e.deleted {
+			return nil, notFound(key)
+		}
+		return e.value, nil
+	}
+	return nil, notFound(key)
+}
+
+// Insert a value with a given key.
+// If the same key has already inserted, the new value overrides old one.
+//
+// If a background compaction has failed, Insert returns its error without
+// inserting. If the insert fills the memtable and the flush fails, Insert
+// returns the error of the flush, but the value stays in the memtable and
+// is flushed by a later Flush or Close.
+func (db *DB) Insert(key int, value interface{}) error {
+	return db.write(key, value)
+}
+
+// Delete remove a value by a given key.
+// If the key does not found, do nothing.
+// Errors are reported as by Insert.
+func (db *DB) Delete(key int) error {
+	return db.write(key, tombstone{})
+}
+
+func (db *DB) write(key int, value interface{}) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+	if err := db.takeErr(); err != nil {
+		return err
+	}
+
+	db.mem.Insert(key, value)
+	if db.mem.Count() < db.opts.MemtableSize {
+		return nil
+	}
+	return db.flush()
+}
+
+// Count returns num of live keys.
+// It merges every table, so it costs as much as a full scan.
+func (db *DB) Count() int {
+	n := 0
+	db.Ascend(func(int, interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// Ascend calls f for each key-value pair in ascending order of keys.
+// If f returns false, Ascend stops the iteration.
+// f must not modify the DB.
+func (db *DB) Ascend(f func(key int, value interface{}) bool) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+
+	next := merge(append([]func() (entry, bool, error){memtable(db.mem)}, iterators(db.tables())...), true)
+	for {
+		e, ok, err := next()
+		if err != nil || !ok {
+			return err
+		}
+		if !f(e.key, e.value) {
+			return nil
+		}
+	}
+}
+
+// Flush writes the memtable to a level 0 table.
+// It also returns the error of a failed background compaction.
+func (db *DB) Flush() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+	if err := db.flush(); err != nil {
+		return err
+	}
+	return db.takeErr()
+}
+
+func (db *DB) flush() error {
+	if db.mem.Count() == 0 {
+		return nil
+	}
+
+	db.seq++
+	t, err := writeTable(db.path(db.seq, 0), db.seq, 0, memtable(db.mem))
+	if err != nil {
+		return err
+	}
+	db.l0 = append(db.l0, t)
+	db.mem = llrb.New()
+
+	if len(db.l0) >= db.opts.L0Tables && !db.compacting {
+		db.compacting = true
+		go db.compact()
+	}
+	return nil
+}
+
+// Compact merges every level 0 table into the level 1 table and waits for
+// the compaction to finish.
+func (db *DB) Compact() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for db.compacting {
+		db.done.Wait()
+	}
+	if db.closed {
+		return ErrClosed
+	}
+	if len(db.l0) == 0 {
+		return nil
+	}
+
+	db.compacting = true
+	db.mu.Unlock()
+	db.compact()
+	db.mu.Lock()
+	return db.takeErr()
+}
+
+// compact runs with db.compacting set. Writes and reads proceed while
+// the merged table is written, because the input tables are immutable.
+func (db *DB) compact() {
+	db.mu.Lock()
+	inputs := append([]*table{}, db.l0...)
+	l1 := db.l1
+	db.seq++
+	seq := db.seq
+	db.mu.Unlock()
+
+	// newest first, as in db.tables
+	sources := []*table{}
+	for i := len(inputs) - 1; i >= 0; i-- {
+		sources = append(sources, inputs[i])
+	}
+	if l1 != nil {
+		sources = append(sources, l1)
+	}
+
+	// Level 1 is the last level, so tombstones can be dropped.
+	t, err := writeTable(db.path(seq, 1), seq, 1, merge(iterators(sources), true))
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.compacting = false
+	if err != nil {
+		db.err = err
+	} else {
+		db.l0 = db.l0[len(inputs):]
+		db.l1 = t
+		for _, t := range sources {
+			t.close()
+			os.Remove(t.path)
+		}
+	}
+	db.done.Broadcast()
+}
+
+// Close flushes the memtable, waits for a running compaction and closes
+// the DB. It also returns the error of a failed background compaction.
+func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return ErrClosed
+	}
+	db.closed = true
+
+	err := db.flush()
+	for db.compacting {
+		db.done.Wait()
+	}
+	if err == nil {
+		err = db.takeErr()
+	}
+	db.closeTables()
+	return err
+}
+
+// takeErr returns the error of a failed compaction once.
+func (db *DB) takeErr() error {
+	err := db.err
+	db.err = nil
+	return err
+}
+
+func (db *DB) closeTables() {
+	for _, t := range db.tables() {
+		t.close()
+	}
+}
+
+// tables returns tables ordered from the newest to the oldest.
+func (db *DB) tables() []*table {
+	tables := []*table{}
+	for i := len(db.l0) - 1; i >= 0; i-- {
+		tables = append(tables, db.l0[i])
+	}
+	if db.l1 != nil {
+		tables = append(tables, db.l1)
+	}
+	return tables
+}
+
+func (db *DB) path(seq, level int) string {
+	return filepath.Join(db.dir, fmt.Sprintf("%06d.L%d.sst", seq, level))
+}
+
+func notFound(key int) error {
+	return fmt.Errorf("found no value by key '%v'", key)
+}
+
+// memtable returns a function yielding the entries of the memtable in order.
+func memtable(mem *llrb.Tree) func() (entry, bool, error) {
+	entries := make([]entry, 0, mem.Count())
+	mem.Ascend(func(key int, value interface{}) bool {
+		_, deleted := value.(tombstone)
+		entries = append(entries, entry{key: key, value: value, deleted: deleted})
+		return true
+	})
+
+	return func() (entry, bool, error) {
+		if len(entries) == 0 {
+			return entry{}, false, nil
+		}
+		e := entries[0]
+		entries = entries[1:]
+		return e, true, nil
+	}
+}
+
+func iterators(tables []*table) []func() (entry, bool, error) {
+	its := []func() (entry, bool, error){}
+	for _, t := range tables {
+		its = append(its, t.iterate())
+	}
+	return its
+}
+
+// merge merges sorted iterators ordered from the newest to the oldest.
+// For a key found in several iterators, the newest entry wins.
+// If drop is true, tombstones are omitted from the result.
+func merge(its []func() (entry, bool, error), drop bool) func() (entry, bool, error) {
+	heads := make([]*entry, len(its))
+	started := false
+
+	advance := func(i int) error {
+		e, ok, err := its[i]()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heads[i] = &e
+		} else {
+			heads[i] = nil
+		}
+		return nil
+	}
+
+	return func() (entry, bool, error) {
+		if !started {
+			started = true
+			for i := range its {
+				if err := advance(i); err != nil {
+					return entry{}, false, err
+				}
+			}
+		}
+
+		for {
+			min := -1
+			for i, h := range heads {
+				if h != nil && (min < 0 || h.key < heads[min].key) {
+					min = i
+				}
+			}
+			if min < 0 {
+				return entry{}, false, nil
+			}
+
+			e := *heads[min]
+			for i, h := range heads {
+				if h != nil && h.key == e.key {
+					if err := advance(i); err != nil {
+						return entry{}, false, err
+					}
+				}
+			}
+
+			if e.deleted && drop {
+				continue
+			}
+			return e, true, nil
+		}
+	}
+}
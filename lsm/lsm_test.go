@@ -0,0 +1,276 @@
+package lsm_test
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/masa-suzu/gtree/lsm"
+	"github.com/masa-suzu/gtree/modeltest"
+)
+
+type kv struct {
+	k int
+	v interface{}
+}
+
+func TestInsertSearch(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir, &lsm.Options{MemtableSize: 4, L0Tables: 100})
+	defer db.Close()
+
+	want := []kv{}
+	for i := 20; i > 0; i-- {
+		insert(t, db, i, i*100)
+		want = append(want, kv{k: i, v: i * 100})
+	}
+
+	assertDB(t, db, want)
+	if got := tables(t, dir); got == 0 {
+		t.Errorf("memtable must be flushed, got %v tables", got)
+	}
+}
+
+func TestSearch_by_InvalidKey(t *testing.T) {
+	db := open(t, t.TempDir(), nil)
+	defer db.Close()
+
+	insert(t, db, 1, 100)
+
+	got, err := db.Search(100)
+
+	if err == nil {
+		t.Errorf("got no error, want '%v'", err)
+	}
+
+	if got != nil {
+		t.Errorf("got %v, want %v", got, nil)
+	}
+}
+
+func TestDelete_Tombstone(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir, &lsm.Options{MemtableSize: 2, L0Tables: 100})
+
+	insert(t, db, 1, 100)
+	insert(t, db, 2, 200)
+	insert(t, db, 3, 300)
+	insert(t, db, 4, 400)
+
+	// the tombstone shadows the flushed value
+	if err := db.Delete(2); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	assertDB(t, db, []kv{{k: 1, v: 100}, {k: 3, v: 300}, {k: 4, v: 400}})
+	if _, err := db.Search(2); err == nil {
+		t.Errorf("got no error for a deleted key")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	db = open(t, dir, nil)
+	defer db.Close()
+	assertDB(t, db, []kv{{k: 1, v: 100}, {k: 3, v: 300}, {k: 4, v: 400}})
+}
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir, &lsm.Options{MemtableSize: 8, L0Tables: 3})
+
+	m := map[int]interface{}{}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		k := r.Intn(300)
+		if r.Intn(3) == 0 {
+			db.Delete(k)
+			delete(m, k)
+			continue
+		}
+		insert(t, db, k, i)
+		m[k] = i
+	}
+
+	if err := db.Compact(); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	if got := tables(t, dir); got != 1 {
+		t.Errorf("want 1 table after compaction, got %v", got)
+	}
+
+	want := []kv{}
+	for k, v := range m {
+		want = append(want, kv{k: k, v: v})
+	}
+	assertDB(t, db, want)
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	db = open(t, dir, nil)
+	defer db.Close()
+	assertDB(t, db, want)
+}
+
+func TestAscend(t *testing.T) {
+	db := open(t, t.TempDir(), &lsm.Options{MemtableSize: 3, L0Tables: 2})
+	defer db.Close()
+
+	for _, k := range []int{6, 10, 1, 9, 8, 2, 7} {
+		insert(t, db, k, k*100)
+	}
+	db.Delete(9)
+	insert(t, db, 6, "600")
+
+	want := []kv{
+		{k: 1, v: 100},
+		{k: 2, v: 200},
+		{k: 6, v: "600"},
+		{k: 7, v: 700},
+		{k: 8, v: 800},
+		{k: 10, v: 1000},
+	}
+
+	got := []kv{}
+	err := db.Ascend(func(key int, value interface{}) bool {
+		got = append(got, kv{k: key, v: value})
+		return true
+	})
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	if len(want) != len(got) {
+		t.Fatalf("num of nodes must be %v, got %v", len(want), len(got))
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Errorf("want %v, got %v", want[i], got[i])
+		}
+	}
+}
+
+func TestInsert_FlushError(t *testing.T) {
+	db := open(t, t.TempDir(), &lsm.Options{MemtableSize: 1, L0Tables: 4})
+	defer db.Close()
+
+	// a channel cannot be encoded into a table
+	ch := make(chan int)
+	if err := db.Insert(1, ch); err == nil {
+		t.Fatalf("got no error")
+	}
+	if v, err := db.Search(1); err != nil || v != ch {
+		t.Errorf("want the value in the memtable, got %v, %v", v, err)
+	}
+}
+
+func TestCompact_BackgroundError(t *testing.T) {
+	dir := t.TempDir()
+	db := open(t, dir, &lsm.Options{MemtableSize: 1 << 10, L0Tables: 2})
+
+	// the compaction of tables 1 and 2 fails to rename its table 3 onto
+	// a non-empty directory
+	if err := os.MkdirAll(filepath.Join(dir, "000003.L1.sst", "x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for k := 1; k <= 2; k++ {
+		insert(t, db, k, nil)
+		if err := db.Flush(); err != nil {
+			t.Fatalf("got an error '%v'", err)
+		}
+	}
+
+	if err := db.Close(); err == nil {
+		t.Errorf("got no error of the compaction")
+	}
+}
+
+// kvs panics on an error of a write, which modeltest reports as a failure.
+type kvs struct {
+	*lsm.DB
+}
+
+func (k kvs) Insert(key int, value interface{}) {
+	if err := k.DB.Insert(key, value); err != nil {
+		panic(err)
+	}
+}
+
+func (k kvs) Delete(key int) {
+	if err := k.DB.Delete(key); err != nil {
+		panic(err)
+	}
+}
+
+func TestModel(t *testing.T) {
+	opts := modeltest.DefaultOptions
+	opts.Runs = 20
+
+	modeltest.Run(t, func() modeltest.KVS {
+		db := open(t, t.TempDir(), &lsm.Options{MemtableSize: 4, L0Tables: 2})
+		t.Cleanup(func() { db.Close() })
+		return kvs{db}
+	}, &opts)
+}
+
+func TestClosed(t *testing.T) {
+	db := open(t, t.TempDir(), nil)
+	db.Close()
+
+	if err := db.Insert(1, 1); err != lsm.ErrClosed {
+		t.Errorf("want '%v', got '%v'", lsm.ErrClosed, err)
+	}
+	if _, err := db.Search(1); err != lsm.ErrClosed {
+		t.Errorf("want '%v', got '%v'", lsm.ErrClosed, err)
+	}
+}
+
+func open(t *testing.T, dir string, opts *lsm.Options) *lsm.DB {
+	t.Helper()
+
+	db, err := lsm.Open(dir, opts)
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	return db
+}
+
+func insert(t *testing.T, db *lsm.DB, key int, value interface{}) {
+	t.Helper()
+
+	if err := db.Insert(key, value); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+}
+
+func tables(t *testing.T, dir string) int {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sst"))
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	return len(paths)
+}
+
+func assertDB(t *testing.T, db *lsm.DB, kvs []kv) {
+	t.Helper()
+
+	if len(kvs) != db.Count() {
+		t.Errorf("num of nodes must be %v, got %v", len(kvs), db.Count())
+	}
+
+	for _, kv := range kvs {
+		got, err := db.Search(kv.k)
+
+		if err != nil {
+			t.Errorf("got an error '%v'", err)
+		}
+		if kv.v != got {
+			t.Errorf("want %v, got %v", kv.v, got)
+		}
+	}
+}
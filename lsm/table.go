@@ -0,0 +1,309 @@
+package lsm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/masa-suzu/gtree/internal/codec"
+)
+
+const (
+	kindValue     byte = 0
+	kindTombstone byte = 1
+
+	// blockSize is the number of records between two index entries.
+	blockSize = 32
+
+	footerSize = 24
+	magic      = 0x67747265656c736d
+)
+
+var errCorrupt = errors.New("lsm: corrupt table")
+
+type entry struct {
+	key     int
+	value   interface{}
+	deleted bool
+}
+
+// table is an immutable sorted file (SSTable).
+// Records are grouped into blocks and only the first key of each block
+// is kept in memory.
+type table struct {
+	path  string
+	seq   int
+	level int
+	f     *os.File
+	end   int64 // end of the data blocks
+	count int
+	keys  []int   // first key of each block
+	offs  []int64 // offset of each block
+}
+
+// writeTable writes entries, which must be sorted by keys, to a new table.
+func writeTable(path string, seq, level int, next func() (entry, bool, error)) (*table, error) {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &table{path: path, seq: seq, level: level}
+	err = t.write(f, next)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	// the rename is durable once the directory is synced
+	if err := syncDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	return openTable(path, seq, level)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (t *table) write(f *os.File, next func() (entry, bool, error)) error {
+	w := bufio.NewWriter(f)
+	var rec, idx []byte
+	var off int64
+	var count int
+
+	for {
+		e, ok, err := next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		if count%blockSize == 0 {
+			idx = binary.AppendVarint(idx, int64(e.key))
+			idx = binary.AppendUvarint(idx, uint64(off))
+		}
+
+		rec, err = appendEntry(rec[:0], e)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(rec); err != nil {
+			return err
+		}
+		off += int64(len(rec))
+		count++
+	}
+
+	footer := make([]byte, footerSize)
+	binary.LittleEndian.PutUint64(footer, uint64(off))
+	binary.LittleEndian.PutUint64(footer[8:], uint64(count))
+	binary.LittleEndian.PutUint64(footer[16:], magic)
+
+	if _, err := w.Write(idx); err != nil {
+		return err
+	}
+	if _, err := w.Write(footer); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func appendEntry(b []byte, e entry) ([]byte, error) {
+	var p []byte
+	p = binary.AppendVarint(p, int64(e.key))
+	if e.deleted {
+		p = append(p, kindTombstone)
+	} else {
+		var err error
+		p = append(p, kindValue)
+		p, err = codec.AppendValue(p, e.value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	b = binary.AppendUvarint(b, uint64(len(p)))
+	return append(b, p...), nil
+}
+
+func decodeEntry(p []byte) (entry, error) {
+	key, n := binary.Varint(p)
+	if n <= 0 || n >= len(p) {
+		return entry{}, errCorrupt
+	}
+
+	e := entry{key: int(key)}
+	switch p[n] {
+	case kindTombstone:
+		e.deleted = true
+	case kindValue:
+		v, _, err := codec.DecodeValue(p[n+1:])
+		if err != nil {
+			return entry{}, err
+		}
+		e.value = v
+	default:
+		return entry{}, errCorrupt
+	}
+	return e, nil
+}
+
+func openTable(path string, seq, level int) (*table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := readIndex(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	t.path = path
+	t.seq = seq
+	t.level = level
+	t.f = f
+	return t, nil
+}
+
+func readIndex(f *os.File) (*table, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() < footerSize {
+		return nil, errCorrupt
+	}
+
+	footer := make([]byte, footerSize)
+	if _, err := f.ReadAt(footer, fi.Size()-footerSize); err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint64(footer[16:]) != magic {
+		return nil, errCorrupt
+	}
+
+	t := &table{
+		end:   int64(binary.LittleEndian.Uint64(footer)),
+		count: int(binary.LittleEndian.Uint64(footer[8:])),
+	}
+	if t.end > fi.Size()-footerSize {
+		return nil, errCorrupt
+	}
+
+	idx := make([]byte, fi.Size()-footerSize-t.end)
+	if _, err := f.ReadAt(idx, t.end); err != nil {
+		return nil, err
+	}
+	for len(idx) > 0 {
+		key, n := binary.Varint(idx)
+		if n <= 0 {
+			return nil, errCorrupt
+		}
+		off, m := binary.Uvarint(idx[n:])
+		if m <= 0 {
+			return nil, errCorrupt
+		}
+		t.keys = append(t.keys, int(key))
+		t.offs = append(t.offs, int64(off))
+		idx = idx[n+m:]
+	}
+	return t, nil
+}
+
+// search looks a key up in the table.
+func (t *table) search(key int) (entry, bool, error) {
+	// find the last block whose first key is not greater than key
+	lo, hi := 0, len(t.keys)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if t.keys[mid] <= key {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo == 0 {
+		return entry{}, false, nil
+	}
+
+	start, end := t.offs[lo-1], t.end
+	if lo < len(t.offs) {
+		end = t.offs[lo]
+	}
+
+	b := make([]byte, end-start)
+	if _, err := t.f.ReadAt(b, start); err != nil {
+		return entry{}, false, err
+	}
+
+	for len(b) > 0 {
+		size, n := binary.Uvarint(b)
+		if n <= 0 || uint64(len(b)-n) < size {
+			return entry{}, false, errCorrupt
+		}
+		e, err := decodeEntry(b[n : n+int(size)])
+		if err != nil {
+			return entry{}, false, err
+		}
+		if e.key == key {
+			return e, true, nil
+		}
+		if e.key > key {
+			break
+		}
+		b = b[n+int(size):]
+	}
+	return entry{}, false, nil
+}
+
+// iterate returns a function yielding the entries of the table in order.
+func (t *table) iterate() func() (entry, bool, error) {
+	r := bufio.NewReader(io.NewSectionReader(t.f, 0, t.end))
+	var p []byte
+
+	return func() (entry, bool, error) {
+		size, err := binary.ReadUvarint(r)
+		if err == io.EOF {
+			return entry{}, false, nil
+		}
+		if err != nil {
+			return entry{}, false, err
+		}
+
+		if uint64(cap(p)) < size {
+			p = make([]byte, size)
+		}
+		p = p[:size]
+		if _, err := io.ReadFull(r, p); err != nil {
+			return entry{}, false, err
+		}
+
+		e, err := decodeEntry(p)
+		return e, err == nil, err
+	}
+}
+
+func (t *table) close() error {
+	return t.f.Close()
+}
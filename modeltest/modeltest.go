@@ -0,0 +1,333 @@
+/*
+	Package modeltest provides a model-based test harness for key-value trees.
+
+	Check generates weighted random command sequences, runs each of them
+	against a fresh implementation and a map-based model, and checks the
+	post-conditions of every command. A failing sequence is shrunk to a
+	minimal one before it is reported.
+*/
+package modeltest
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// KVS is the interface under test.
+// It has the same methods as the kvs interface of the benchmark package.
+type KVS interface {
+	Search(key int) (interface{}, error)
+	Insert(key int, value interface{})
+	Delete(key int)
+	Count() int
+}
+
+// Ascender is implemented by trees supporting in-order iteration.
+// Iterate commands are skipped for trees implementing neither Ascender
+// nor ErrAscender.
+type Ascender interface {
+	Ascend(f func(key int, value interface{}) bool)
+}
+
+// ErrAscender is implemented by trees whose iteration can fail, such as
+// btree.Tree and lsm.DB. An error of Ascend fails the Iterate command.
+type ErrAscender interface {
+	Ascend(f func(key int, value interface{}) bool) error
+}
+
+// Validator is implemented by trees checking their own invariants.
+// If implemented, Validate is called after every command.
+type Validator interface {
+	Validate() error
+}
+
+// Weights are the relative frequencies of generated commands.
+// A command which is impossible in the current state, such as deleting
+// a present key from an empty tree, is generated as a Search instead.
+type Weights struct {
+	Insert        int // insert a new key
+	Overwrite     int // insert a present key
+	DeleteMissing int
+	DeletePresent int
+	Search        int
+	Iterate       int
+}
+
+// Options configures Check.
+type Options struct {
+	Seed     int64
+	Runs     int // num of sequences
+	Steps    int // num of commands in a sequence
+	KeySpace int // keys are drawn from [0, KeySpace)
+	Weights  Weights
+}
+
+// DefaultOptions is used when Check is called with nil options.
+var DefaultOptions = Options{
+	Seed:     1,
+	Runs:     100,
+	Steps:    200,
+	KeySpace: 64,
+	Weights: Weights{
+		Insert:        4,
+		Overwrite:     1,
+		DeleteMissing: 1,
+		DeletePresent: 3,
+		Search:        2,
+		Iterate:       1,
+	},
+}
+
+// Kind is a kind of command.
+type Kind int
+
+// Kinds of commands.
+const (
+	Insert Kind = iota
+	Delete
+	Search
+	Iterate
+)
+
+// Command is a step of a sequence.
+type Command struct {
+	Kind  Kind
+	Key   int
+	Value int
+}
+
+func (c Command) String() string {
+	switch c.Kind {
+	case Insert:
+		return fmt.Sprintf("Insert(%v, %v)", c.Key, c.Value)
+	case Delete:
+		return fmt.Sprintf("Delete(%v)", c.Key)
+	case Search:
+		return fmt.Sprintf("Search(%v)", c.Key)
+	}
+	return "Ascend(...)"
+}
+
+// Failure is a shrunk failing sequence.
+type Failure struct {
+	Seed     int64
+	Commands []Command
+	Err      error // the broken post-condition of the last command
+}
+
+func (f *Failure) Error() string {
+	s := []string{}
+	for _, c := range f.Commands {
+		s = append(s, "tree."+c.String())
+	}
+	return fmt.Sprintf("%v\nafter %v commands of seed %v:\n\t%v", f.Err, len(f.Commands), f.Seed, strings.Join(s, "\n\t"))
+}
+
+// Run calls Check and fails t with the shrunk sequence.
+func Run(t testing.TB, newKVS func() KVS, opts *Options) {
+	t.Helper()
+
+	if f := Check(newKVS, opts); f != nil {
+		t.Fatal(f)
+	}
+}
+
+// Check runs random sequences and returns the first failure, shrunk,
+// or nil if every sequence passed.
+func Check(newKVS func() KVS, opts *Options) *Failure {
+	if opts == nil {
+		opts = &DefaultOptions
+	}
+
+	for i := 0; i < opts.Runs; i++ {
+		seed := opts.Seed + int64(i)
+		cmds := generate(rand.New(rand.NewSource(seed)), opts)
+
+		if n, err := execute(newKVS(), cmds); err != nil {
+			cmds = shrink(newKVS, cmds[:n+1])
+			_, err = execute(newKVS(), cmds)
+			return &Failure{Seed: seed, Commands: cmds, Err: err}
+		}
+	}
+	return nil
+}
+
+func generate(r *rand.Rand, opts *Options) []Command {
+	w := opts.Weights
+	total := w.Insert + w.Overwrite + w.DeleteMissing + w.DeletePresent + w.Search + w.Iterate
+	if total <= 0 {
+		return nil
+	}
+
+	present := map[int]bool{}
+	cmds := make([]Command, 0, opts.Steps)
+
+	// pick returns a present key, or a missing one, if any.
+	pick := func(want bool) (int, bool) {
+		for try := 0; try < 2*opts.KeySpace; try++ {
+			k := r.Intn(opts.KeySpace)
+			if present[k] == want {
+				return k, true
+			}
+		}
+		return 0, false
+	}
+
+	for len(cmds) < opts.Steps {
+		x := r.Intn(total)
+		var c Command
+		var ok bool
+
+		switch {
+		case x < w.Insert:
+			c.Kind = Insert
+			c.Key, ok = pick(false)
+		case x < w.Insert+w.Overwrite:
+			c.Kind = Insert
+			c.Key, ok = pick(true)
+		case x < w.Insert+w.Overwrite+w.DeleteMissing:
+			c.Kind = Delete
+			c.Key, ok = pick(false)
+		case x < w.Insert+w.Overwrite+w.DeleteMissing+w.DeletePresent:
+			c.Kind = Delete
+			c.Key, ok = pick(true)
+		case x < total-w.Iterate:
+			c.Kind = Search
+			c.Key, ok = r.Intn(opts.KeySpace), true
+		default:
+			c.Kind = Iterate
+			ok = true
+		}
+		if !ok {
+			c.Kind = Search
+			c.Key = r.Intn(opts.KeySpace)
+		}
+
+		switch c.Kind {
+		case Insert:
+			c.Value = len(cmds)
+			present[c.Key] = true
+		case Delete:
+			delete(present, c.Key)
+		}
+		cmds = append(cmds, c)
+	}
+	return cmds
+}
+
+// execute runs cmds and returns the index of the first failing command.
+func execute(kvs KVS, cmds []Command) (n int, err error) {
+	model := map[int]interface{}{}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	for n = range cmds {
+		if err = step(kvs, model, cmds[n]); err != nil {
+			return n, err
+		}
+	}
+	return 0, nil
+}
+
+func step(kvs KVS, model map[int]interface{}, c Command) error {
+	switch c.Kind {
+	case Insert:
+		kvs.Insert(c.Key, c.Value)
+		model[c.Key] = c.Value
+	case Delete:
+		kvs.Delete(c.Key)
+		delete(model, c.Key)
+	case Search:
+		got, err := kvs.Search(c.Key)
+		want, ok := model[c.Key]
+		if ok && (err != nil || got != want) {
+			return fmt.Errorf("Search(%v) returned (%v, %v), want %v", c.Key, got, err, want)
+		}
+		if !ok && err == nil {
+			return fmt.Errorf("Search(%v) returned %v, want an error", c.Key, got)
+		}
+	case Iterate:
+		if err := iterate(kvs, model); err != nil {
+			return err
+		}
+	}
+
+	if got := kvs.Count(); got != len(model) {
+		return fmt.Errorf("Count() returned %v, want %v", got, len(model))
+	}
+	if v, ok := kvs.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// iterate checks that Ascend yields the model in order.
+// It does nothing for trees which cannot ascend.
+func iterate(kvs KVS, model map[int]interface{}) error {
+	var ascend func(f func(key int, value interface{}) bool) error
+	switch a := kvs.(type) {
+	case Ascender:
+		ascend = func(f func(key int, value interface{}) bool) error {
+			a.Ascend(f)
+			return nil
+		}
+	case ErrAscender:
+		ascend = a.Ascend
+	default:
+		return nil
+	}
+
+	keys := make([]int, 0, len(model))
+	for k := range model {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	got := []int{}
+	var err error
+	aerr := ascend(func(key int, value interface{}) bool {
+		if err == nil && len(got) < len(keys) && value != model[key] {
+			err = fmt.Errorf("Ascend yielded %v for key %v, want %v", value, key, model[key])
+		}
+		got = append(got, key)
+		return true
+	})
+	if aerr != nil {
+		return fmt.Errorf("Ascend returned an error '%v'", aerr)
+	}
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(got) != fmt.Sprint(keys) {
+		return fmt.Errorf("Ascend yielded keys %v, want %v", got, keys)
+	}
+	return nil
+}
+
+// shrink removes commands from a failing sequence as long as it fails.
+// It tries to remove chunks from the largest to single commands.
+func shrink(newKVS func() KVS, cmds []Command) []Command {
+	fails := func(cmds []Command) bool {
+		_, err := execute(newKVS(), cmds)
+		return err != nil
+	}
+
+	for size := len(cmds) / 2; size >= 1; size /= 2 {
+		for i := 0; i+size <= len(cmds); {
+			c := append(append([]Command{}, cmds[:i]...), cmds[i+size:]...)
+			if fails(c) {
+				cmds = c
+			} else {
+				i += size
+			}
+		}
+	}
+	return cmds
+}
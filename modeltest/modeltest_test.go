@@ -0,0 +1,142 @@
+package modeltest_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/masa-suzu/gtree/avl"
+	"github.com/masa-suzu/gtree/modeltest"
+)
+
+// buggy ignores a deletion of the smallest key while it holds 3 or more keys.
+type buggy struct {
+	*avl.Tree
+}
+
+func (b buggy) Delete(key int) {
+	if b.Count() >= 3 {
+		min := 0
+		b.Ascend(func(k int, v interface{}) bool {
+			min = k
+			return false
+		})
+		if key == min {
+			return
+		}
+	}
+	b.Tree.Delete(key)
+}
+
+// panicky panics on the key 7.
+type panicky struct {
+	*avl.Tree
+}
+
+func (p panicky) Insert(key int, value interface{}) {
+	if key == 7 {
+		panic("key 7")
+	}
+	p.Tree.Insert(key, value)
+}
+
+// broken fails to ascend while it holds 2 or more keys.
+type broken struct {
+	*avl.Tree
+}
+
+func (b broken) Ascend(f func(key int, value interface{}) bool) error {
+	if b.Count() >= 2 {
+		return errors.New("broken page")
+	}
+	b.Tree.Ascend(f)
+	return nil
+}
+
+func TestCheck_Passes(t *testing.T) {
+	modeltest.Run(t, func() modeltest.KVS { return avl.New() }, nil)
+}
+
+func TestCheck_Shrinks(t *testing.T) {
+	tests := []struct {
+		name   string
+		newKVS func() modeltest.KVS
+		want   int
+	}{
+		{
+			name:   "ignored-delete",
+			newKVS: func() modeltest.KVS { return buggy{avl.New()} },
+			want:   4,
+		},
+		{
+			name:   "panic",
+			newKVS: func() modeltest.KVS { return panicky{avl.New()} },
+			want:   1,
+		},
+		{
+			name:   "ascend-error",
+			newKVS: func() modeltest.KVS { return broken{avl.New()} },
+			want:   3,
+		},
+	}
+
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := modeltest.Check(tt.newKVS, nil)
+			if f == nil {
+				t.Fatalf("got no failure")
+			}
+
+			if len(f.Commands) != tt.want {
+				t.Errorf("want %v commands, got %v", tt.want, f)
+			}
+			opts := modeltest.DefaultOptions
+			opts.Seed = f.Seed
+			opts.Runs = 1
+			if modeltest.Check(tt.newKVS, &opts) == nil {
+				t.Errorf("failure must reproduce with seed %v", f.Seed)
+			}
+		})
+	}
+}
+
+func TestCheck_ImpossibleWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights modeltest.Weights
+	}{
+		{name: "overwrite", weights: modeltest.Weights{Overwrite: 1}},
+		{name: "delete-present", weights: modeltest.Weights{DeletePresent: 1}},
+		{name: "delete-missing", weights: modeltest.Weights{Insert: 1, DeleteMissing: 100}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			opts := modeltest.DefaultOptions
+			opts.Runs = 10
+			opts.KeySpace = 4
+			opts.Weights = tt.weights
+
+			modeltest.Run(t, func() modeltest.KVS { return avl.New() }, &opts)
+		})
+	}
+}
+
+func TestFailure_Error(t *testing.T) {
+	f := &modeltest.Failure{
+		Seed: 3,
+		Commands: []modeltest.Command{
+			{Kind: modeltest.Insert, Key: 1, Value: 0},
+			{Kind: modeltest.Delete, Key: 1},
+		},
+		Err: fmt.Errorf("Count() returned 1, want 0"),
+	}
+
+	want := "Count() returned 1, want 0\nafter 2 commands of seed 3:\n\ttree.Insert(1, 0)\n\ttree.Delete(1)"
+	if got := f.Error(); want != got {
+		t.Errorf("\nwant\n%v\ngot\n%v", want, got)
+	}
+}
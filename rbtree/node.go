@@ -0,0 +1,15 @@
+package rbtree
+
+const (
+	red   = true
+	black = false
+)
+
+type node struct {
+	key    int
+	value  interface{}
+	left   *node
+	right  *node
+	parent *node
+	color  bool
+}
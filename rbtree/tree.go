@@ -0,0 +1,340 @@
+/*
+	Package rbtree provides an implementation of Red-Black Tree.
+	It follows "Introduction to Algorithms" (Cormen, Leiserson, Rivest and Stein):
+	nodes have parent pointers, and insert and delete are fixed up by loops
+	instead of recursion.
+*/
+package rbtree
+
+import (
+	"fmt"
+)
+
+const (
+	lt = -1
+	eq = 0
+	gt = 1
+)
+
+// Tree implements a red-black tree.
+type Tree struct {
+	root  *node
+	null  *node // sentinel used for every leaf and the parent of the root
+	count int
+}
+
+// New returns a reference to an empty Tree.
+func New() *Tree {
+	null := &node{color: black}
+	return &Tree{
+		root:  null,
+		null:  null,
+		count: 0,
+	}
+}
+
+// Count returns num of nodes.
+func (t *Tree) Count() int {
+	return t.count
+}
+
+// Search returns a value associated with a given key.
+// If no value is found by the key, returns nil with an error.
+func (t *Tree) Search(key int) (interface{}, error) {
+	if x := t.find(key); x != t.null {
+		return x.value, nil
+	}
+	return nil, fmt.Errorf("found no value by key '%v'", key)
+}
+
+func (t *Tree) find(key int) *node {
+	x := t.root
+
+	for x != t.null {
+		cmp := compare(key, x.key)
+		switch cmp {
+		case eq:
+			return x
+		case lt:
+			x = x.left
+		case gt:
+			x = x.right
+		}
+	}
+	return t.null
+}
+
+// Insert a value with a given key.
+// If the same key has already inserted, the new value overrides old one.
+func (t *Tree) Insert(key int, value interface{}) {
+	y := t.null
+	x := t.root
+	cmp := eq
+
+	for x != t.null {
+		y = x
+		cmp = compare(key, x.key)
+		switch cmp {
+		case eq:
+			x.value = value
+			return
+		case lt:
+			x = x.left
+		case gt:
+			x = x.right
+		}
+	}
+
+	z := &node{
+		key:    key,
+		value:  value,
+		left:   t.null,
+		right:  t.null,
+		parent: y,
+		color:  red,
+	}
+	switch {
+	case y == t.null:
+		t.root = z
+	case cmp == lt:
+		y.left = z
+	default:
+		y.right = z
+	}
+	t.count++
+	t.insertFixup(z)
+}
+
+func (t *Tree) insertFixup(z *node) {
+	for z.parent.color == red {
+		if z.parent == z.parent.parent.left {
+			y := z.parent.parent.right
+			if y.color == red {
+				z.parent.color = black
+				y.color = black
+				z.parent.parent.color = red
+				z = z.parent.parent
+				continue
+			}
+			if z == z.parent.right {
+				z = z.parent
+				t.rotateLeft(z)
+			}
+			z.parent.color = black
+			z.parent.parent.color = red
+			t.rotateRight(z.parent.parent)
+		} else {
+			y := z.parent.parent.left
+			if y.color == red {
+				z.parent.color = black
+				y.color = black
+				z.parent.parent.color = red
+				z = z.parent.parent
+				continue
+			}
+			if z == z.parent.left {
+				z = z.parent
+				t.rotateRight(z)
+			}
+			z.parent.color = black
+			z.parent.parent.color = red
+			t.rotateLeft(z.parent.parent)
+		}
+	}
+	t.root.color = black
+}
+
+// Delete remove a node by a given key.
+// If the key does not found, do nothing.
+func (t *Tree) Delete(key int) {
+	z := t.find(key)
+	if z == t.null {
+		return
+	}
+	t.count--
+
+	var x *node
+	y := z
+	color := y.color
+
+	switch {
+	case z.left == t.null:
+		x = z.right
+		t.transplant(z, z.right)
+	case z.right == t.null:
+		x = z.left
+		t.transplant(z, z.left)
+	default:
+		y = t.min(z.right)
+		color = y.color
+		x = y.right
+		if y.parent == z {
+			x.parent = y
+		} else {
+			t.transplant(y, y.right)
+			y.right = z.right
+			y.right.parent = y
+		}
+		t.transplant(z, y)
+		y.left = z.left
+		y.left.parent = y
+		y.color = z.color
+	}
+
+	if color == black {
+		t.deleteFixup(x)
+	}
+	// the sentinel may have been given a parent by transplant
+	t.null.parent = nil
+}
+
+func (t *Tree) deleteFixup(x *node) {
+	for x != t.root && x.color == black {
+		if x == x.parent.left {
+			w := x.parent.right
+			if w.color == red {
+				w.color = black
+				x.parent.color = red
+				t.rotateLeft(x.parent)
+				w = x.parent.right
+			}
+			if w.left.color == black && w.right.color == black {
+				w.color = red
+				x = x.parent
+				continue
+			}
+			if w.right.color == black {
+				w.left.color = black
+				w.color = red
+				t.rotateRight(w)
+				w = x.parent.right
+			}
+			w.color = x.parent.color
+			x.parent.color = black
+			w.right.color = black
+			t.rotateLeft(x.parent)
+			x = t.root
+		} else {
+			w := x.parent.left
+			if w.color == red {
+				w.color = black
+				x.parent.color = red
+				t.rotateRight(x.parent)
+				w = x.parent.left
+			}
+			if w.right.color == black && w.left.color == black {
+				w.color = red
+				x = x.parent
+				continue
+			}
+			if w.left.color == black {
+				w.right.color = black
+				w.color = red
+				t.rotateLeft(w)
+				w = x.parent.left
+			}
+			w.color = x.parent.color
+			x.parent.color = black
+			w.left.color = black
+			t.rotateRight(x.parent)
+			x = t.root
+		}
+	}
+	x.color = black
+}
+
+// transplant replaces the subtree of u with the subtree of v.
+func (t *Tree) transplant(u, v *node) {
+	switch {
+	case u.parent == t.null:
+		t.root = v
+	case u == u.parent.left:
+		u.parent.left = v
+	default:
+		u.parent.right = v
+	}
+	v.parent = u.parent
+}
+
+func (t *Tree) rotateLeft(x *node) {
+	y := x.right
+	x.right = y.left
+	if y.left != t.null {
+		y.left.parent = x
+	}
+	y.parent = x.parent
+	switch {
+	case x.parent == t.null:
+		t.root = y
+	case x == x.parent.left:
+		x.parent.left = y
+	default:
+		x.parent.right = y
+	}
+	y.left = x
+	x.parent = y
+}
+
+func (t *Tree) rotateRight(x *node) {
+	y := x.left
+	x.left = y.right
+	if y.right != t.null {
+		y.right.parent = x
+	}
+	y.parent = x.parent
+	switch {
+	case x.parent == t.null:
+		t.root = y
+	case x == x.parent.right:
+		x.parent.right = y
+	default:
+		x.parent.left = y
+	}
+	y.right = x
+	x.parent = y
+}
+
+func (t *Tree) min(n *node) *node {
+	for n.left != t.null {
+		n = n.left
+	}
+	return n
+}
+
+// Ascend calls f for each key-value pair in ascending order of keys.
+// If f returns false, Ascend stops the iteration.
+// It follows parent pointers, so it needs no stack.
+func (t *Tree) Ascend(f func(key int, value interface{}) bool) {
+	if t.root == t.null {
+		return
+	}
+
+	for n := t.min(t.root); n != t.null; n = t.next(n) {
+		if !f(n.key, n.value) {
+			return
+		}
+	}
+}
+
+// next returns the in-order successor of n.
+func (t *Tree) next(n *node) *node {
+	if n.right != t.null {
+		return t.min(n.right)
+	}
+	p := n.parent
+	for p != t.null && n == p.right {
+		n = p
+		p = p.parent
+	}
+	return p
+}
+
+func compare(k1, k2 int) int {
+	if k1 < k2 {
+		return lt
+	}
+	if k1 > k2 {
+		return gt
+	}
+	return eq
+}
@@ -0,0 +1,12 @@
+package rbtree_test
+
+import (
+	"testing"
+
+	"github.com/masa-suzu/gtree/modeltest"
+	"github.com/masa-suzu/gtree/rbtree"
+)
+
+func TestInvariants(t *testing.T) {
+	modeltest.Run(t, func() modeltest.KVS { return rbtree.New() }, nil)
+}
@@ -0,0 +1,68 @@
+package rbtree
+
+import "fmt"
+
+// Validate checks the invariants of the tree: keys are in order, Count
+// matches num of nodes, the root is black, no red node has a red child,
+// every path from the root to a leaf has the same num of black nodes, and
+// parent pointers link children to their parents.
+// It returns an error for the first violation in preorder.
+func (t *Tree) Validate() error {
+	if t.root != t.null && t.root.color != black {
+		return fmt.Errorf("rbtree: root must be black")
+	}
+	if t.root != t.null && t.root.parent != t.null {
+		return fmt.Errorf("rbtree: parent of root must be the sentinel")
+	}
+
+	n, _, err := t.validate(t.root, nil, nil)
+	if err != nil {
+		return err
+	}
+	if n != t.count {
+		return fmt.Errorf("rbtree: Count() is %v, but the tree has %v nodes", t.count, n)
+	}
+	return nil
+}
+
+// validate checks n, whose keys must be in (lo, hi), and returns num of
+// nodes and the black height.
+func (t *Tree) validate(n *node, lo, hi *int) (int, int, error) {
+	if n == t.null {
+		return 0, 1, nil
+	}
+
+	if lo != nil && n.key <= *lo {
+		return 0, 0, fmt.Errorf("rbtree: key %v must be greater than %v", n.key, *lo)
+	}
+	if hi != nil && n.key >= *hi {
+		return 0, 0, fmt.Errorf("rbtree: key %v must be less than %v", n.key, *hi)
+	}
+	for _, c := range []*node{n.left, n.right} {
+		if c == t.null {
+			continue
+		}
+		if c.parent != n {
+			return 0, 0, fmt.Errorf("rbtree: parent of %v must be %v", c.key, n.key)
+		}
+		if n.color == red && c.color == red {
+			return 0, 0, fmt.Errorf("rbtree: red %v has a red child %v", n.key, c.key)
+		}
+	}
+
+	l, lb, err := t.validate(n.left, lo, &n.key)
+	if err != nil {
+		return 0, 0, err
+	}
+	r, rb, err := t.validate(n.right, &n.key, hi)
+	if err != nil {
+		return 0, 0, err
+	}
+	if lb != rb {
+		return 0, 0, fmt.Errorf("rbtree: black heights under %v differ: %v and %v", n.key, lb, rb)
+	}
+	if n.color == black {
+		lb++
+	}
+	return 1 + l + r, lb, nil
+}
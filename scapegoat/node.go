@@ -0,0 +1,15 @@
+package scapegoat
+
+type node struct {
+	key   int
+	value interface{}
+	left  *node
+	right *node
+}
+
+func size(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return 1 + size(n.left) + size(n.right)
+}
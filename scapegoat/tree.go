@@ -0,0 +1,242 @@
+/*
+	Package scapegoat provides an implementation of Scapegoat Tree.
+	Original implementation is available from https://people.csail.mit.edu/rivest/pubs/GR93.pdf.
+
+	Nodes hold no balance data. When an insertion goes deeper than
+	log(n) / log(1/alpha), the highest unbalanced ancestor (the scapegoat)
+	is rebuilt into a perfectly balanced subtree. When deletions shrink the
+	tree below alpha times its size at the last rebuild, the whole tree is
+	rebuilt.
+*/
+package scapegoat
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	lt = -1
+	eq = 0
+	gt = 1
+)
+
+// DefaultAlpha is the balance factor used by New.
+const DefaultAlpha = 0.7
+
+// Tree implements a scapegoat tree.
+type Tree struct {
+	root     *node
+	count    int
+	maxCount int // max of count since the last rebuild of the whole tree
+	alpha    float64
+	path     []*node
+}
+
+// New returns a reference to an empty Tree.
+func New() *Tree {
+	t, _ := NewWithAlpha(DefaultAlpha)
+	return t
+}
+
+// NewWithAlpha returns a reference to an empty Tree with a given balance
+// factor. alpha must be in [0.5, 1); a lower alpha keeps the tree shallower
+// at the cost of more rebuilds.
+func NewWithAlpha(alpha float64) (*Tree, error) {
+	if alpha < 0.5 || alpha >= 1 {
+		return nil, fmt.Errorf("alpha must be in [0.5, 1), got %v", alpha)
+	}
+	return &Tree{
+		root:  nil,
+		count: 0,
+		alpha: alpha,
+	}, nil
+}
+
+// Count returns num of nodes.
+func (t *Tree) Count() int {
+	return t.count
+}
+
+// Search returns a value associated with a given key.
+// If no value is found by the key, returns nil with an error.
+func (t *Tree) Search(key int) (interface{}, error) {
+	x := t.root
+
+	for x != nil {
+		cmp := compare(key, x.key)
+		switch cmp {
+		case eq:
+			return x.value, nil
+		case lt:
+			x = x.left
+		case gt:
+			x = x.right
+		}
+	}
+	return nil, fmt.Errorf("found no value by key '%v'", key)
+}
+
+// Insert a value with a given key.
+// If the same key has already inserted, the new value overrides old one.
+func (t *Tree) Insert(key int, value interface{}) {
+	t.path = t.path[:0]
+	link := &t.root
+
+	for *link != nil {
+		n := *link
+		t.path = append(t.path, n)
+		switch compare(key, n.key) {
+		case eq:
+			n.value = value
+			return
+		case lt:
+			link = &n.left
+		case gt:
+			link = &n.right
+		}
+	}
+
+	x := &node{
+		key:   key,
+		value: value,
+	}
+	*link = x
+	t.count++
+	if t.count > t.maxCount {
+		t.maxCount = t.count
+	}
+
+	if len(t.path) <= t.depthLimit() {
+		return
+	}
+
+	// find the scapegoat on the way back up
+	s := 1
+	child := x
+	for i := len(t.path) - 1; i >= 0; i-- {
+		n := t.path[i]
+		sibling := n.left
+		if sibling == child {
+			sibling = n.right
+		}
+		total := s + 1 + size(sibling)
+		if float64(s) > t.alpha*float64(total) {
+			t.replace(i, rebuild(n, total))
+			return
+		}
+		s = total
+		child = n
+	}
+}
+
+// depthLimit returns the max depth allowed for a tree with count nodes.
+func (t *Tree) depthLimit() int {
+	return int(math.Log(float64(t.count)) / math.Log(1/t.alpha))
+}
+
+// replace puts n in place of t.path[i].
+func (t *Tree) replace(i int, n *node) {
+	if i == 0 {
+		t.root = n
+		return
+	}
+	p := t.path[i-1]
+	if p.left == t.path[i] {
+		p.left = n
+	} else {
+		p.right = n
+	}
+}
+
+// Delete remove a node by a given key.
+// If the key does not found, do nothing.
+func (t *Tree) Delete(key int) {
+	link := &t.root
+
+	for *link != nil {
+		n := *link
+		switch compare(key, n.key) {
+		case lt:
+			link = &n.left
+			continue
+		case gt:
+			link = &n.right
+			continue
+		}
+
+		switch {
+		case n.left == nil:
+			*link = n.right
+		case n.right == nil:
+			*link = n.left
+		default:
+			// replace n by the max of its left subtree
+			m := &n.left
+			for (*m).right != nil {
+				m = &(*m).right
+			}
+			x := *m
+			*m = x.left
+			n.key, n.value = x.key, x.value
+		}
+		t.count--
+		break
+	}
+
+	if float64(t.count) < t.alpha*float64(t.maxCount) {
+		t.root = rebuild(t.root, t.count)
+		t.maxCount = t.count
+	}
+}
+
+// rebuild returns a perfectly balanced tree of the size nodes of n.
+func rebuild(n *node, size int) *node {
+	nodes := make([]*node, 0, size)
+	var flatten func(*node)
+	flatten = func(n *node) {
+		if n == nil {
+			return
+		}
+		flatten(n.left)
+		nodes = append(nodes, n)
+		flatten(n.right)
+	}
+	flatten(n)
+
+	return build(nodes)
+}
+
+func build(nodes []*node) *node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	mid := len(nodes) / 2
+	n := nodes[mid]
+	n.left = build(nodes[:mid])
+	n.right = build(nodes[mid+1:])
+	return n
+}
+
+// Ascend calls f for each key-value pair in ascending order of keys.
+// If f returns false, Ascend stops the iteration.
+func (t *Tree) Ascend(f func(key int, value interface{}) bool) {
+	ascend(t.root, f)
+}
+
+func ascend(n *node, f func(int, interface{}) bool) bool {
+	if n == nil {
+		return true
+	}
+	return ascend(n.left, f) && f(n.key, n.value) && ascend(n.right, f)
+}
+
+func compare(k1, k2 int) int {
+	if k1 < k2 {
+		return lt
+	}
+	if k1 > k2 {
+		return gt
+	}
+	return eq
+}
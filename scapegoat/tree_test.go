@@ -0,0 +1,31 @@
+package scapegoat_test
+
+import (
+	"testing"
+
+	"github.com/masa-suzu/gtree/modeltest"
+	"github.com/masa-suzu/gtree/scapegoat"
+)
+
+func TestInsert_Ascending_Shallow(t *testing.T) {
+	tree := scapegoat.New()
+	for i := 0; i < 2000; i++ {
+		tree.Insert(i, i)
+
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("step %v: %v", i, err)
+		}
+	}
+}
+
+func TestNewWithAlpha_Invalid(t *testing.T) {
+	for _, alpha := range []float64{0.4, 1, 1.5} {
+		if _, err := scapegoat.NewWithAlpha(alpha); err == nil {
+			t.Errorf("got no error for alpha %v", alpha)
+		}
+	}
+}
+
+func TestInvariants(t *testing.T) {
+	modeltest.Run(t, func() modeltest.KVS { return scapegoat.New() }, nil)
+}
@@ -0,0 +1,55 @@
+package scapegoat
+
+import (
+	"fmt"
+	"math"
+)
+
+// Validate checks the invariants of the tree: keys are in order, Count
+// matches num of nodes, and no node is deeper than the scapegoat bound
+// log(maxCount) / log(1/alpha) + 1, where maxCount is the max of Count
+// since the last rebuild of the whole tree.
+// It returns an error for the first violation in preorder.
+func (t *Tree) Validate() error {
+	limit := 0
+	if t.maxCount > 0 {
+		limit = int(math.Log(float64(t.maxCount))/math.Log(1/t.alpha)) + 1
+	}
+
+	n, err := validate(t.root, 0, limit, nil, nil)
+	if err != nil {
+		return err
+	}
+	if n != t.count {
+		return fmt.Errorf("scapegoat: Count() is %v, but the tree has %v nodes", t.count, n)
+	}
+	return nil
+}
+
+// validate checks n, which is at depth and whose keys must be in (lo, hi),
+// and returns num of nodes.
+func validate(n *node, depth, limit int, lo, hi *int) (int, error) {
+	if n == nil {
+		return 0, nil
+	}
+
+	if depth > limit {
+		return 0, fmt.Errorf("scapegoat: %v is at depth %v, deeper than %v", n.key, depth, limit)
+	}
+	if lo != nil && n.key <= *lo {
+		return 0, fmt.Errorf("scapegoat: key %v must be greater than %v", n.key, *lo)
+	}
+	if hi != nil && n.key >= *hi {
+		return 0, fmt.Errorf("scapegoat: key %v must be less than %v", n.key, *hi)
+	}
+
+	l, err := validate(n.left, depth+1, limit, lo, &n.key)
+	if err != nil {
+		return 0, err
+	}
+	r, err := validate(n.right, depth+1, limit, &n.key, hi)
+	if err != nil {
+		return 0, err
+	}
+	return 1 + l + r, nil
+}
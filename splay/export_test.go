@@ -0,0 +1,6 @@
+package splay
+
+// Root returns the key at the root.
+func (t *Tree) Root() int {
+	return t.root.key
+}
@@ -0,0 +1,8 @@
+package splay
+
+type node struct {
+	key   int
+	value interface{}
+	left  *node
+	right *node
+}
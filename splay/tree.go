@@ -0,0 +1,206 @@
+/*
+	Package splay provides an implementation of top-down Splay Tree.
+	Original implementation is available from https://www.cs.cmu.edu/~sleator/papers/self-adjusting.pdf.
+
+	Every operation, including Search, moves the accessed key to the root,
+	so recently used keys are found in a few steps. Because Search mutates
+	the tree, a Tree is not safe for concurrent reads: guard every method
+	with a sync.Mutex, not a sync.RWMutex.
+*/
+package splay
+
+import (
+	"fmt"
+)
+
+const (
+	lt = -1
+	eq = 0
+	gt = 1
+)
+
+// Tree implements a splay tree.
+type Tree struct {
+	root  *node
+	count int
+}
+
+// New returns a reference to an empty Tree.
+func New() *Tree {
+	return &Tree{
+		root:  nil,
+		count: 0,
+	}
+}
+
+// Count returns num of nodes.
+func (t *Tree) Count() int {
+	return t.count
+}
+
+// Search returns a value associated with a given key.
+// If no value is found by the key, returns nil with an error.
+// Search splays the tree, so it must not run concurrently with any other
+// method, including Search itself.
+func (t *Tree) Search(key int) (interface{}, error) {
+	t.root = splay(t.root, key)
+
+	if t.root == nil || compare(key, t.root.key) != eq {
+		return nil, fmt.Errorf("found no value by key '%v'", key)
+	}
+	return t.root.value, nil
+}
+
+// Insert a value with a given key.
+// If the same key has already inserted, the new value overrides old one.
+func (t *Tree) Insert(key int, value interface{}) {
+	if t.root == nil {
+		t.count++
+		t.root = &node{
+			key:   key,
+			value: value,
+		}
+		return
+	}
+
+	n := splay(t.root, key)
+	cmp := compare(key, n.key)
+	if cmp == eq {
+		n.value = value
+		t.root = n
+		return
+	}
+
+	x := &node{
+		key:   key,
+		value: value,
+	}
+	if cmp == lt {
+		x.left = n.left
+		x.right = n
+		n.left = nil
+	} else {
+		x.right = n.right
+		x.left = n
+		n.right = nil
+	}
+	t.count++
+	t.root = x
+}
+
+// Delete remove a node by a given key.
+// If the key does not found, do nothing.
+func (t *Tree) Delete(key int) {
+	if t.root == nil {
+		return
+	}
+
+	n := splay(t.root, key)
+	if compare(key, n.key) != eq {
+		t.root = n
+		return
+	}
+
+	t.count--
+	if n.left == nil {
+		t.root = n.right
+		return
+	}
+
+	// key is greater than every key in n.left, so its max becomes the root
+	x := splay(n.left, key)
+	x.right = n.right
+	t.root = x
+}
+
+// splay moves the node with key, or the last node on the search path,
+// to the root of n.
+func splay(n *node, key int) *node {
+	if n == nil {
+		return nil
+	}
+
+	// header.right holds the left tree and header.left holds the right tree.
+	var header node
+	l, r := &header, &header
+
+	for {
+		cmp := compare(key, n.key)
+		if cmp == lt {
+			if n.left == nil {
+				break
+			}
+			if compare(key, n.left.key) == lt {
+				n = rotateRight(n)
+				if n.left == nil {
+					break
+				}
+			}
+			// link right
+			r.left = n
+			r = n
+			n = n.left
+		} else if cmp == gt {
+			if n.right == nil {
+				break
+			}
+			if compare(key, n.right.key) == gt {
+				n = rotateLeft(n)
+				if n.right == nil {
+					break
+				}
+			}
+			// link left
+			l.right = n
+			l = n
+			n = n.right
+		} else {
+			break
+		}
+	}
+
+	// assemble
+	l.right = n.left
+	r.left = n.right
+	n.left = header.right
+	n.right = header.left
+	return n
+}
+
+func rotateLeft(n *node) *node {
+	x := n.right
+	n.right = x.left
+	x.left = n
+	return x
+}
+
+func rotateRight(n *node) *node {
+	x := n.left
+	n.left = x.right
+	x.right = n
+	return x
+}
+
+// Ascend calls f for each key-value pair in ascending order of keys.
+// If f returns false, Ascend stops the iteration.
+// Unlike Search, Ascend does not modify the tree.
+func (t *Tree) Ascend(f func(key int, value interface{}) bool) {
+	ascend(t.root, f)
+}
+
+func ascend(n *node, f func(int, interface{}) bool) bool {
+	if n == nil {
+		return true
+	}
+	return ascend(n.left, f) && f(n.key, n.value) && ascend(n.right, f)
+}
+
+func compare(k1, k2 int) int {
+	if k1 < k2 {
+		return lt
+	}
+	if k1 > k2 {
+		return gt
+	}
+	return eq
+}
@@ -0,0 +1,23 @@
+package splay_test
+
+import (
+	"testing"
+
+	"github.com/masa-suzu/gtree/splay"
+)
+
+func TestSearch_Splays(t *testing.T) {
+	tree := splay.New()
+	for i := 1; i <= 100; i++ {
+		tree.Insert(i, i)
+	}
+
+	for _, k := range []int{1, 50, 73, 100} {
+		if _, err := tree.Search(k); err != nil {
+			t.Fatalf("got an error '%v'", err)
+		}
+		if tree.Root() != k {
+			t.Errorf("key %v must be at the root, got %v", k, tree.Root())
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package trace
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ReplayOptions configures Replay.
+type ReplayOptions struct {
+	// NoCheck skips checking the results of searches against the trace.
+	NoCheck bool
+}
+
+// PhaseReport is the result of a phase of a trace.
+type PhaseReport struct {
+	Name     string
+	Inserts  int
+	Deletes  int
+	Searches int
+	Duration time.Duration
+}
+
+// Ops returns num of operations of the phase.
+func (p PhaseReport) Ops() int {
+	return p.Inserts + p.Deletes + p.Searches
+}
+
+// Report is the result of Replay.
+type Report struct {
+	// Phases are the phases in the order of the trace.
+	// Operations before the first phase mark make a phase named "".
+	Phases []PhaseReport
+	// Count is num of nodes of the tree after the replay.
+	Count int
+}
+
+// Duration returns the total time of phases.
+func (r *Report) Duration() time.Duration {
+	d := time.Duration(0)
+	for _, p := range r.Phases {
+		d += p.Duration
+	}
+	return d
+}
+
+// MismatchError is returned by Replay when a search returns a result
+// different from the recorded one.
+type MismatchError struct {
+	// Index is the index of the operation in the trace.
+	Index int
+	Op    Op
+	Got   interface{}
+	Found bool
+}
+
+func (e *MismatchError) Error() string {
+	want := "no value"
+	if e.Op.Kind == SearchHit {
+		want = fmt.Sprintf("%v", e.Op.Value)
+	}
+	got := "no value"
+	if e.Found {
+		got = fmt.Sprintf("%v", e.Got)
+	}
+	return fmt.Sprintf("trace: op %v: %v returned %v, want %v", e.Index, e.Op, got, want)
+}
+
+// Replay runs ops read by ReadAll against tree and times each phase.
+// Unless opts.NoCheck is set, it returns a *MismatchError for the first
+// search whose result differs from the trace. The results are checked
+// after each phase, so that checking is not timed.
+func Replay(ops []Op, tree KVS, opts *ReplayOptions) (*Report, error) {
+	if opts == nil {
+		opts = &ReplayOptions{}
+	}
+
+	type result struct {
+		value interface{}
+		found bool
+	}
+	results := make([]result, len(ops))
+	report := &Report{}
+
+	for start := 0; start < len(ops); {
+		p := PhaseReport{}
+		if ops[start].Kind == Phase {
+			p.Name = ops[start].Name
+			start++
+		}
+		end := start
+		for end < len(ops) && ops[end].Kind != Phase {
+			end++
+		}
+
+		t := time.Now()
+		for i := start; i < end; i++ {
+			o := &ops[i]
+			switch o.Kind {
+			case Insert:
+				tree.Insert(o.Key, o.Value)
+			case Delete:
+				tree.Delete(o.Key)
+			case SearchHit, SearchMiss:
+				v, err := tree.Search(o.Key)
+				results[i] = result{value: v, found: err == nil}
+			}
+		}
+		p.Duration = time.Since(t)
+
+		for i := start; i < end; i++ {
+			o := ops[i]
+			switch o.Kind {
+			case Insert:
+				p.Inserts++
+			case Delete:
+				p.Deletes++
+			case SearchHit, SearchMiss:
+				p.Searches++
+				if opts.NoCheck {
+					continue
+				}
+				r := results[i]
+				if r.found != (o.Kind == SearchHit) || (r.found && !reflect.DeepEqual(r.value, o.Value)) {
+					return nil, &MismatchError{Index: i, Op: o, Got: r.value, Found: r.found}
+				}
+			}
+		}
+
+		if p.Name != "" || p.Ops() > 0 {
+			report.Phases = append(report.Phases, p)
+		}
+		start = end
+	}
+
+	report.Count = tree.Count()
+	return report, nil
+}
@@ -0,0 +1,296 @@
+/*
+	Package trace records the operations made on a tree into a compact
+	trace and replays them against any tree.
+
+	A trace starts with a header and holds a record per operation:
+
+		header: "gtrc" version(1 byte)
+		record: len(uvarint) kind(1 byte) key(varint delta) [value] [name]
+
+	Keys are stored as the differences from the previous keys, so that
+	sequential keys take a byte. Inserted values and the results of
+	searches are encoded by internal/codec, so that they must be nil, int,
+	string, []byte, bool, float64 or types registered by gob.Register.
+*/
+package trace
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/masa-suzu/gtree/internal/codec"
+)
+
+const (
+	magic   = "gtrc"
+	version = 1
+
+	// maxRecordSize limits the length of a record read from a trace.
+	maxRecordSize = 64 << 20
+)
+
+// ErrCorrupt is returned when a trace cannot be decoded.
+var ErrCorrupt = errors.New("trace: corrupt trace")
+
+// KVS is the interface of recorded and replayed trees.
+type KVS interface {
+	Search(key int) (interface{}, error)
+	Insert(key int, value interface{})
+	Delete(key int)
+	Count() int
+}
+
+// Kind is a kind of operation.
+type Kind byte
+
+// Kinds of operations.
+const (
+	Insert     Kind = iota + 1
+	Delete          // Delete of a key, present or not
+	SearchHit       // Search which found a value
+	SearchMiss      // Search which returned an error
+	Phase           // a mark starting a named phase
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Insert:
+		return "Insert"
+	case Delete:
+		return "Delete"
+	case SearchHit, SearchMiss:
+		return "Search"
+	case Phase:
+		return "Phase"
+	}
+	return fmt.Sprintf("Kind(%d)", byte(k))
+}
+
+// Op is a recorded operation.
+type Op struct {
+	Kind  Kind
+	Key   int
+	Value interface{} // the inserted or found value
+	Name  string      // the name of a phase
+}
+
+func (o Op) String() string {
+	switch o.Kind {
+	case Insert:
+		return fmt.Sprintf("Insert(%v, %v)", o.Key, o.Value)
+	case Delete, SearchHit, SearchMiss:
+		return fmt.Sprintf("%v(%v)", o.Kind, o.Key)
+	case Phase:
+		return fmt.Sprintf("Phase(%q)", o.Name)
+	}
+	return o.Kind.String()
+}
+
+// Recorder is a KVS which records the operations on an underlying tree.
+// Like the trees, a Recorder must not be used concurrently.
+type Recorder struct {
+	tree KVS
+	w    *bufio.Writer
+	prev int
+	buf  []byte
+	err  error
+}
+
+// NewRecorder returns a Recorder writing the operations on tree to w.
+// Close must be called to flush the trace.
+func NewRecorder(tree KVS, w io.Writer) *Recorder {
+	r := &Recorder{tree: tree, w: bufio.NewWriter(w)}
+	if _, r.err = r.w.WriteString(magic); r.err == nil {
+		r.err = r.w.WriteByte(version)
+	}
+	return r
+}
+
+// Search searches the tree and records the result.
+func (r *Recorder) Search(key int) (interface{}, error) {
+	v, err := r.tree.Search(key)
+	if err != nil {
+		r.write(Op{Kind: SearchMiss, Key: key})
+	} else {
+		r.write(Op{Kind: SearchHit, Key: key, Value: v})
+	}
+	return v, err
+}
+
+// Insert inserts a value into the tree and records it.
+func (r *Recorder) Insert(key int, value interface{}) {
+	r.tree.Insert(key, value)
+	r.write(Op{Kind: Insert, Key: key, Value: value})
+}
+
+// Delete deletes a key from the tree and records it.
+func (r *Recorder) Delete(key int) {
+	r.tree.Delete(key)
+	r.write(Op{Kind: Delete, Key: key})
+}
+
+// Count returns num of nodes of the tree. It is not recorded.
+func (r *Recorder) Count() int {
+	return r.tree.Count()
+}
+
+// Phase starts a named phase, e.g. "load" or "serve".
+// Replay reports the time of each phase.
+func (r *Recorder) Phase(name string) {
+	r.write(Op{Kind: Phase, Name: name})
+}
+
+// Err returns the first error of recording, e.g. a write error or a value
+// which cannot be encoded. Operations on the tree succeed even after an
+// error, but are not recorded any more.
+func (r *Recorder) Err() error {
+	return r.err
+}
+
+// Close flushes the trace and returns the first error of recording.
+// It does not close the underlying writer.
+func (r *Recorder) Close() error {
+	if r.err != nil {
+		return r.err
+	}
+	r.err = r.w.Flush()
+	return r.err
+}
+
+func (r *Recorder) write(o Op) {
+	if r.err != nil {
+		return
+	}
+
+	b := append(r.buf[:0], byte(o.Kind))
+	if o.Kind != Phase {
+		b = binary.AppendVarint(b, int64(o.Key-r.prev))
+		r.prev = o.Key
+	}
+	switch o.Kind {
+	case Insert, SearchHit:
+		if b, r.err = codec.AppendValue(b, o.Value); r.err != nil {
+			return
+		}
+	case Phase:
+		b = binary.AppendUvarint(b, uint64(len(o.Name)))
+		b = append(b, o.Name...)
+	}
+	r.buf = b
+
+	var n [binary.MaxVarintLen64]byte
+	if _, r.err = r.w.Write(n[:binary.PutUvarint(n[:], uint64(len(b)))]); r.err != nil {
+		return
+	}
+	_, r.err = r.w.Write(b)
+}
+
+// Reader reads operations from a trace.
+type Reader struct {
+	r    *bufio.Reader
+	prev int
+	buf  []byte
+}
+
+// NewReader reads the header of a trace and returns a Reader of its
+// operations.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+
+	h := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(br, h); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrCorrupt
+		}
+		return nil, err
+	}
+	if string(h[:len(magic)]) != magic {
+		return nil, ErrCorrupt
+	}
+	if h[len(magic)] != version {
+		return nil, fmt.Errorf("trace: unsupported version %v", h[len(magic)])
+	}
+	return &Reader{r: br}, nil
+}
+
+// Next returns the next operation.
+// It returns io.EOF at the end of the trace, and ErrCorrupt for a broken
+// or truncated record.
+func (r *Reader) Next() (Op, error) {
+	l, err := binary.ReadUvarint(r.r)
+	if err == io.EOF {
+		return Op{}, io.EOF
+	}
+	if err != nil || l == 0 || l > maxRecordSize {
+		return Op{}, ErrCorrupt
+	}
+
+	if uint64(cap(r.buf)) < l {
+		r.buf = make([]byte, l)
+	}
+	b := r.buf[:l]
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return Op{}, ErrCorrupt
+	}
+
+	o := Op{Kind: Kind(b[0])}
+	b = b[1:]
+	if o.Kind < Insert || o.Kind > Phase {
+		return Op{}, ErrCorrupt
+	}
+
+	if o.Kind != Phase {
+		d, n := binary.Varint(b)
+		if n <= 0 {
+			return Op{}, ErrCorrupt
+		}
+		b = b[n:]
+		o.Key = r.prev + int(d)
+		r.prev = o.Key
+	}
+
+	switch o.Kind {
+	case Insert, SearchHit:
+		v, n, err := codec.DecodeValue(b)
+		if err != nil {
+			return Op{}, ErrCorrupt
+		}
+		b = b[n:]
+		o.Value = v
+	case Phase:
+		l, n := binary.Uvarint(b)
+		if n <= 0 || uint64(len(b)-n) < l {
+			return Op{}, ErrCorrupt
+		}
+		o.Name = string(b[n : n+int(l)])
+		b = b[n+int(l):]
+	}
+
+	if len(b) != 0 {
+		return Op{}, ErrCorrupt
+	}
+	return o, nil
+}
+
+// ReadAll reads every operation of a trace.
+func ReadAll(r io.Reader) ([]Op, error) {
+	tr, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := []Op{}
+	for {
+		o, err := tr.Next()
+		if err == io.EOF {
+			return ops, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, o)
+	}
+}
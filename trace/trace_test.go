@@ -0,0 +1,183 @@
+package trace_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/masa-suzu/gtree/avl"
+	"github.com/masa-suzu/gtree/llrb"
+	"github.com/masa-suzu/gtree/trace"
+)
+
+func record(t *testing.T) []byte {
+	t.Helper()
+
+	w := &bytes.Buffer{}
+	r := trace.NewRecorder(avl.New(), w)
+
+	r.Phase("load")
+	r.Insert(10, 100)
+	r.Insert(-5, "x")
+	r.Insert(7, []byte{1, 2})
+	r.Insert(8, nil)
+	r.Phase("serve")
+	r.Search(10)
+	r.Search(11)
+	r.Delete(7)
+	r.Search(7)
+	r.Insert(10, 1.5)
+	r.Search(10)
+
+	if got := r.Count(); got != 3 {
+		t.Errorf("want 3 nodes, got %v", got)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	return w.Bytes()
+}
+
+func TestReadAll(t *testing.T) {
+	ops, err := trace.ReadAll(bytes.NewReader(record(t)))
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	want := []string{
+		`Phase("load")`,
+		"Insert(10, 100)",
+		"Insert(-5, x)",
+		"Insert(7, [1 2])",
+		"Insert(8, <nil>)",
+		`Phase("serve")`,
+		"Search(10)",
+		"Search(11)",
+		"Delete(7)",
+		"Search(7)",
+		"Insert(10, 1.5)",
+		"Search(10)",
+	}
+	if len(want) != len(ops) {
+		t.Fatalf("want %v ops, got %v", len(want), ops)
+	}
+	for i := range want {
+		if got := ops[i].String(); want[i] != got {
+			t.Errorf("want %v, got %v", want[i], got)
+		}
+	}
+	if ops[6].Kind != trace.SearchHit || ops[6].Value != 100 {
+		t.Errorf("want a hit of 100, got %#v", ops[6])
+	}
+	if ops[7].Kind != trace.SearchMiss {
+		t.Errorf("want a miss, got %#v", ops[7])
+	}
+}
+
+func TestReplay(t *testing.T) {
+	ops, err := trace.ReadAll(bytes.NewReader(record(t)))
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	report, err := trace.Replay(ops, llrb.New(), nil)
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	if report.Count != 3 {
+		t.Errorf("want 3 nodes, got %v", report.Count)
+	}
+	if len(report.Phases) != 2 {
+		t.Fatalf("want 2 phases, got %+v", report.Phases)
+	}
+	load, serve := report.Phases[0], report.Phases[1]
+	if load.Name != "load" || load.Inserts != 4 || load.Ops() != 4 {
+		t.Errorf("want 4 inserts of load, got %+v", load)
+	}
+	if serve.Name != "serve" || serve.Inserts != 1 || serve.Deletes != 1 || serve.Searches != 4 {
+		t.Errorf("want 1 insert, 1 delete and 4 searches of serve, got %+v", serve)
+	}
+}
+
+func TestReplay_Mismatch(t *testing.T) {
+	ops, err := trace.ReadAll(bytes.NewReader(record(t)))
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	tree := llrb.New()
+	tree.Insert(11, "stale")
+
+	_, err = trace.Replay(ops, tree, nil)
+	m, ok := err.(*trace.MismatchError)
+	if !ok {
+		t.Fatalf("want a *MismatchError, got '%v'", err)
+	}
+	if m.Index != 7 || !m.Found || m.Got != "stale" {
+		t.Errorf("want a mismatch of Search(11), got %+v", m)
+	}
+
+	tree = llrb.New()
+	tree.Insert(11, "stale")
+	if _, err := trace.Replay(ops, tree, &trace.ReplayOptions{NoCheck: true}); err != nil {
+		t.Errorf("got an error '%v'", err)
+	}
+}
+
+func TestReader_Corrupt(t *testing.T) {
+	b := record(t)
+
+	// every truncation but the one at a record boundary is detected
+	for i := 0; i < len(b); i++ {
+		_, err := trace.ReadAll(bytes.NewReader(b[:i]))
+		if err != nil && err != trace.ErrCorrupt {
+			t.Errorf("truncated at %v: got an error '%v'", i, err)
+		}
+	}
+
+	if _, err := trace.ReadAll(bytes.NewReader([]byte("nope!"))); err != trace.ErrCorrupt {
+		t.Errorf("want '%v', got '%v'", trace.ErrCorrupt, err)
+	}
+
+	// a record of an unknown kind
+	broken := append(append([]byte{}, b...), 3, 99, 0, 0)
+	if _, err := trace.ReadAll(bytes.NewReader(broken)); err != trace.ErrCorrupt {
+		t.Errorf("want '%v', got '%v'", trace.ErrCorrupt, err)
+	}
+}
+
+func TestRecorder_Compact(t *testing.T) {
+	w := &bytes.Buffer{}
+	r := trace.NewRecorder(avl.New(), w)
+	for i := 0; i < 1000; i++ {
+		r.Insert(i, i%64)
+	}
+	for i := 0; i < 1000; i++ {
+		r.Search(i)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	// len, kind, key delta, tag and value, but the delta from 999 to 0
+	if got := w.Len(); got > 5+2000*5+1 {
+		t.Errorf("want at most 5 bytes per op, got %v bytes", got)
+	}
+}
+
+func TestRecorder_Error(t *testing.T) {
+	r := trace.NewRecorder(avl.New(), io.Discard)
+	r.Insert(1, make(chan int))
+	r.Insert(2, 2)
+
+	if r.Err() == nil {
+		t.Errorf("got no error for a value which cannot be encoded")
+	}
+	if err := r.Close(); err == nil {
+		t.Errorf("got no error")
+	}
+	if got := r.Count(); got != 2 {
+		t.Errorf("want 2 nodes, got %v", got)
+	}
+}
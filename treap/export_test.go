@@ -0,0 +1,17 @@
+package treap
+
+// Preorder returns keys in preorder, which determines the shape of the tree.
+func (t *Tree) Preorder() []int {
+	keys := []int{}
+	var walk func(*node)
+	walk = func(n *node) {
+		if n == nil {
+			return
+		}
+		keys = append(keys, n.key)
+		walk(n.left)
+		walk(n.right)
+	}
+	walk(t.root)
+	return keys
+}
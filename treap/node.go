@@ -0,0 +1,22 @@
+package treap
+
+type node struct {
+	key      int
+	value    interface{}
+	priority int64
+	size     int
+	left     *node
+	right    *node
+}
+
+func size(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *node) update() *node {
+	n.size = 1 + size(n.left) + size(n.right)
+	return n
+}
@@ -0,0 +1,215 @@
+/*
+	Package treap provides an implementation of Treap.
+
+	A treap keeps keys in binary search tree order and random priorities in
+	heap order, so it is balanced in expectation. Insert and Delete are built
+	on split and merge instead of rotations.
+*/
+package treap
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	lt = -1
+	eq = 0
+	gt = 1
+)
+
+// Tree implements a treap.
+type Tree struct {
+	root *node
+	rand *rand.Rand
+}
+
+// New returns a reference to an empty Tree with priorities seeded by time.
+func New() *Tree {
+	return NewWithSource(rand.NewSource(time.Now().UnixNano()))
+}
+
+// NewWithSource returns a reference to an empty Tree drawing priorities
+// from src. The same source yields the same tree shapes.
+func NewWithSource(src rand.Source) *Tree {
+	return &Tree{
+		root: nil,
+		rand: rand.New(src),
+	}
+}
+
+// Count returns num of nodes.
+func (t *Tree) Count() int {
+	return size(t.root)
+}
+
+// Search returns a value associated with a given key.
+// If no value is found by the key, returns nil with an error.
+func (t *Tree) Search(key int) (interface{}, error) {
+	if n := find(t.root, key); n != nil {
+		return n.value, nil
+	}
+	return nil, fmt.Errorf("found no value by key '%v'", key)
+}
+
+// Insert a value with a given key.
+// If the same key has already inserted, the new value overrides old one.
+func (t *Tree) Insert(key int, value interface{}) {
+	if n := find(t.root, key); n != nil {
+		n.value = value
+		return
+	}
+
+	t.root = insert(t.root, &node{
+		key:      key,
+		value:    value,
+		priority: t.rand.Int63(),
+		size:     1,
+	})
+}
+
+// insert puts x, whose key is not in n, into n.
+func insert(n *node, x *node) *node {
+	if n == nil {
+		return x
+	}
+
+	if x.priority > n.priority {
+		x.left, x.right = split(n, x.key)
+		return x.update()
+	}
+
+	if compare(x.key, n.key) == lt {
+		n.left = insert(n.left, x)
+	} else {
+		n.right = insert(n.right, x)
+	}
+	return n.update()
+}
+
+// Delete remove a node by a given key.
+// If the key does not found, do nothing.
+func (t *Tree) Delete(key int) {
+	t.root = remove(t.root, key)
+}
+
+func remove(n *node, key int) *node {
+	if n == nil {
+		return nil
+	}
+
+	switch compare(key, n.key) {
+	case lt:
+		n.left = remove(n.left, key)
+	case gt:
+		n.right = remove(n.right, key)
+	case eq:
+		return merge(n.left, n.right)
+	}
+	return n.update()
+}
+
+// Split moves the keys less than key to a new Tree and the others to
+// another new Tree, leaving t empty.
+func (t *Tree) Split(key int) (*Tree, *Tree) {
+	l, r := split(t.root, key)
+	t.root = nil
+	return &Tree{root: l, rand: t.rand}, &Tree{root: r, rand: t.rand}
+}
+
+// Merge moves every key of other into t, leaving other empty.
+// Every key of t must be less than every key of other.
+func (t *Tree) Merge(other *Tree) error {
+	if t.root != nil && other.root != nil && max(t.root).key >= min(other.root).key {
+		return fmt.Errorf("keys of merged tree must be greater than '%v'", max(t.root).key)
+	}
+
+	t.root = merge(t.root, other.root)
+	other.root = nil
+	return nil
+}
+
+// split divides n into the nodes with keys less than key and the others.
+func split(n *node, key int) (*node, *node) {
+	if n == nil {
+		return nil, nil
+	}
+
+	if n.key < key {
+		l, r := split(n.right, key)
+		n.right = l
+		return n.update(), r
+	}
+	l, r := split(n.left, key)
+	n.left = r
+	return l, n.update()
+}
+
+// merge joins l and r, where every key of l is less than every key of r.
+func merge(l, r *node) *node {
+	if l == nil {
+		return r
+	}
+	if r == nil {
+		return l
+	}
+
+	if l.priority > r.priority {
+		l.right = merge(l.right, r)
+		return l.update()
+	}
+	r.left = merge(l, r.left)
+	return r.update()
+}
+
+func find(n *node, key int) *node {
+	for n != nil {
+		switch compare(key, n.key) {
+		case eq:
+			return n
+		case lt:
+			n = n.left
+		case gt:
+			n = n.right
+		}
+	}
+	return nil
+}
+
+func min(n *node) *node {
+	for n.left != nil {
+		n = n.left
+	}
+	return n
+}
+
+func max(n *node) *node {
+	for n.right != nil {
+		n = n.right
+	}
+	return n
+}
+
+// Ascend calls f for each key-value pair in ascending order of keys.
+// If f returns false, Ascend stops the iteration.
+func (t *Tree) Ascend(f func(key int, value interface{}) bool) {
+	ascend(t.root, f)
+}
+
+func ascend(n *node, f func(int, interface{}) bool) bool {
+	if n == nil {
+		return true
+	}
+	return ascend(n.left, f) && f(n.key, n.value) && ascend(n.right, f)
+}
+
+func compare(k1, k2 int) int {
+	if k1 < k2 {
+		return lt
+	}
+	if k1 > k2 {
+		return gt
+	}
+	return eq
+}
@@ -0,0 +1,105 @@
+package treap_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/masa-suzu/gtree/treap"
+)
+
+func TestNewWithSource_Deterministic(t *testing.T) {
+	shape := func() []int {
+		tree := treap.NewWithSource(rand.NewSource(42))
+		for i := 0; i < 100; i++ {
+			tree.Insert(i, i)
+		}
+		for i := 0; i < 100; i += 3 {
+			tree.Delete(i)
+		}
+		return tree.Preorder()
+	}
+
+	want, got := shape(), shape()
+	if len(want) != len(got) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Fatalf("want %v, got %v", want, got)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tree := treap.NewWithSource(rand.NewSource(1))
+	for i := 1; i <= 10; i++ {
+		tree.Insert(i, i*100)
+	}
+
+	l, r := tree.Split(4)
+
+	if tree.Count() != 0 {
+		t.Errorf("num of nodes must be %v, got %v", 0, tree.Count())
+	}
+	assertTree(t, l, []kv{{k: 1, v: 100}, {k: 2, v: 200}, {k: 3, v: 300}})
+	assertTree(t, r, []kv{
+		{k: 4, v: 400}, {k: 5, v: 500}, {k: 6, v: 600}, {k: 7, v: 700},
+		{k: 8, v: 800}, {k: 9, v: 900}, {k: 10, v: 1000},
+	})
+}
+
+func TestMerge(t *testing.T) {
+	l := treap.NewWithSource(rand.NewSource(1))
+	r := treap.NewWithSource(rand.NewSource(2))
+	want := []kv{}
+	for i := 1; i <= 5; i++ {
+		l.Insert(i, i)
+		r.Insert(i+5, i+5)
+		want = append(want, kv{k: i, v: i}, kv{k: i + 5, v: i + 5})
+	}
+
+	if err := l.Merge(r); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	if r.Count() != 0 {
+		t.Errorf("num of nodes must be %v, got %v", 0, r.Count())
+	}
+	assertTree(t, l, want)
+}
+
+func TestMerge_Overlapping(t *testing.T) {
+	l := treap.New()
+	r := treap.New()
+	l.Insert(5, 5)
+	r.Insert(5, 50)
+
+	if err := l.Merge(r); err == nil {
+		t.Errorf("got no error for overlapping keys")
+	}
+	assertTree(t, l, []kv{{k: 5, v: 5}})
+	assertTree(t, r, []kv{{k: 5, v: 50}})
+}
+
+type kv struct {
+	k int
+	v interface{}
+}
+
+func assertTree(t *testing.T, tree *treap.Tree, kvs []kv) {
+	t.Helper()
+
+	if len(kvs) != tree.Count() {
+		t.Errorf("num of nodes must be %v, got %v", len(kvs), tree.Count())
+	}
+
+	for _, kv := range kvs {
+		got, err := tree.Search(kv.k)
+
+		if err != nil {
+			t.Errorf("got an error '%v'", err)
+		}
+		if kv.v != got {
+			t.Errorf("want %v, got %v", kv.v, got)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package veb
+
+// node is a van Emde Boas structure over the universe [0, 1<<bits).
+// The min is not stored in any cluster. Clusters are allocated lazily,
+// so the space is proportional to num of keys rather than the universe.
+//
+// The value of a key is held by the node where the key is the min, or by
+// the max of a node of 1 bit, which is the only other place a key is
+// stored without being passed down.
+type node struct {
+	bits     int
+	min      int // -1 if empty
+	max      int
+	minValue interface{}
+	maxValue interface{} // only for 1 bit and max != min
+	summary  *node
+	clusters map[int]*node
+}
+
+func newNode(bits int) *node {
+	return &node{
+		bits: bits,
+		min:  -1,
+		max:  -1,
+	}
+}
+
+func (n *node) lowBits() int {
+	return n.bits / 2
+}
+
+func (n *node) high(x int) int {
+	return x >> n.lowBits()
+}
+
+func (n *node) low(x int) int {
+	return x & (1<<n.lowBits() - 1)
+}
+
+func (n *node) index(h, l int) int {
+	return h<<n.lowBits() | l
+}
+
+// find returns the place of the value of x, or nil if x is not present.
+func (n *node) find(x int) *interface{} {
+	for n != nil {
+		if x == n.min {
+			return &n.minValue
+		}
+		if n.bits == 1 {
+			if x == n.max {
+				return &n.maxValue
+			}
+			return nil
+		}
+		n, x = n.clusters[n.high(x)], n.low(x)
+	}
+	return nil
+}
+
+// insert inserts x, which must not be present, with value v.
+func (n *node) insert(x int, v interface{}) {
+	if n.min == -1 {
+		n.min = x
+		n.max = x
+		n.minValue = v
+		return
+	}
+
+	if x < n.min {
+		x, n.min = n.min, x
+		v, n.minValue = n.minValue, v
+	}
+
+	if n.bits > 1 {
+		h, l := n.high(x), n.low(x)
+		if n.clusters == nil {
+			n.clusters = map[int]*node{}
+			n.summary = newNode(n.bits - n.lowBits())
+		}
+		c, ok := n.clusters[h]
+		if !ok {
+			c = newNode(n.lowBits())
+			n.clusters[h] = c
+			n.summary.insert(h, nil)
+		}
+		c.insert(l, v)
+	} else {
+		n.maxValue = v
+	}
+
+	if x > n.max {
+		n.max = x
+	}
+}
+
+// delete deletes x, which must be present.
+func (n *node) delete(x int) {
+	if n.min == n.max {
+		n.min = -1
+		n.max = -1
+		n.minValue = nil
+		return
+	}
+
+	if n.bits == 1 {
+		if x == 0 {
+			n.minValue = n.maxValue
+		}
+		n.maxValue = nil
+		n.min = 1 - x
+		n.max = n.min
+		return
+	}
+
+	// the new min moves out of its cluster with its value
+	if x == n.min {
+		h := n.summary.min
+		c := n.clusters[h]
+		x = n.index(h, c.min)
+		n.min = x
+		n.minValue = c.minValue
+	}
+
+	h := n.high(x)
+	c := n.clusters[h]
+	c.delete(n.low(x))
+
+	if c.min == -1 {
+		delete(n.clusters, h)
+		n.summary.delete(h)
+		if x == n.max {
+			if s := n.summary.max; s == -1 {
+				n.max = n.min
+			} else {
+				n.max = n.index(s, n.clusters[s].max)
+			}
+		}
+	} else if x == n.max {
+		n.max = n.index(h, c.max)
+	}
+}
+
+// successor returns the smallest key greater than x, or -1.
+func (n *node) successor(x int) int {
+	if n.bits == 1 {
+		if x == 0 && n.max == 1 {
+			return 1
+		}
+		return -1
+	}
+	if n.min != -1 && x < n.min {
+		return n.min
+	}
+	if n.clusters == nil {
+		return -1
+	}
+
+	h, l := n.high(x), n.low(x)
+	if c, ok := n.clusters[h]; ok && l < c.max {
+		return n.index(h, c.successor(l))
+	}
+
+	s := n.summary.successor(h)
+	if s == -1 {
+		return -1
+	}
+	return n.index(s, n.clusters[s].min)
+}
+
+// predecessor returns the largest key less than x, or -1.
+func (n *node) predecessor(x int) int {
+	if n.bits == 1 {
+		if x == 1 && n.min == 0 {
+			return 0
+		}
+		return -1
+	}
+	if n.max != -1 && x > n.max {
+		return n.max
+	}
+	if n.clusters == nil {
+		if n.min != -1 && x > n.min {
+			return n.min
+		}
+		return -1
+	}
+
+	h, l := n.high(x), n.low(x)
+	if c, ok := n.clusters[h]; ok && l > c.min {
+		return n.index(h, c.predecessor(l))
+	}
+
+	p := n.summary.predecessor(h)
+	if p == -1 {
+		if n.min != -1 && x > n.min {
+			return n.min
+		}
+		return -1
+	}
+	return n.index(p, n.clusters[p].max)
+}
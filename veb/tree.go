@@ -0,0 +1,175 @@
+/*
+	Package veb provides an implementation of van Emde Boas Tree for keys in
+	a bounded universe [0, 1<<bits).
+
+	Insert, Delete, Search, Successor and Predecessor take O(log log U) time
+	for a universe of size U. Values are kept in the nodes of the structure,
+	so that Search walks down the clusters as the others do. Keys outside
+	of the universe are never found, and Insert ignores them and keeps an
+	error for Err, so check keys from outside with InRange first.
+*/
+package veb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultBits is the size of the universe used by New, in bits.
+const DefaultBits = 32
+
+// MaxBits is the largest size of a universe in bits.
+const MaxBits = 62
+
+// ErrOutOfRange is kept by Insert when a key is outside of the universe.
+var ErrOutOfRange = errors.New("veb: key out of range")
+
+// Tree implements a van Emde Boas tree with values associated with keys.
+type Tree struct {
+	root  *node
+	count int
+	bits  int
+	err   error
+}
+
+// New returns a reference to an empty Tree over 32-bit keys.
+func New() *Tree {
+	t, _ := NewWithBits(DefaultBits)
+	return t
+}
+
+// NewWithBits returns a reference to an empty Tree over keys in [0, 1<<bits).
+func NewWithBits(bits int) (*Tree, error) {
+	if bits < 1 || bits > MaxBits {
+		return nil, fmt.Errorf("bits must be in [1, %v], got %v", MaxBits, bits)
+	}
+	return &Tree{
+		root: newNode(bits),
+		bits: bits,
+	}, nil
+}
+
+// Count returns num of keys.
+func (t *Tree) Count() int {
+	return t.count
+}
+
+// Err returns an error wrapping ErrOutOfRange for the first key rejected
+// by Insert, or nil.
+func (t *Tree) Err() error {
+	return t.err
+}
+
+// Search returns a value associated with a given key.
+// If no value is found by the key, returns nil with an error.
+func (t *Tree) Search(key int) (interface{}, error) {
+	if v := t.find(key); v != nil {
+		return *v, nil
+	}
+	return nil, fmt.Errorf("found no value by key '%v'", key)
+}
+
+func (t *Tree) find(key int) *interface{} {
+	if !t.InRange(key) {
+		return nil
+	}
+	return t.root.find(key)
+}
+
+// InRange reports whether key is in the universe of the tree.
+// Check keys from outside with it before Insert, which cannot report
+// an error by itself.
+func (t *Tree) InRange(key int) bool {
+	return key >= 0 && key < 1<<t.bits
+}
+
+// Insert a value with a given key.
+// If the same key has already inserted, the new value overrides old one.
+// A key outside of the universe is not inserted, and Err reports it.
+func (t *Tree) Insert(key int, value interface{}) {
+	if !t.InRange(key) {
+		if t.err == nil {
+			t.err = fmt.Errorf("%w: '%v' is not in [0, %v)", ErrOutOfRange, key, 1<<t.bits)
+		}
+		return
+	}
+
+	if v := t.root.find(key); v != nil {
+		*v = value
+		return
+	}
+	t.root.insert(key, value)
+	t.count++
+}
+
+// Delete remove a key.
+// If the key does not found, do nothing.
+func (t *Tree) Delete(key int) {
+	if t.find(key) == nil {
+		return
+	}
+	t.root.delete(key)
+	t.count--
+}
+
+// Min returns the smallest key.
+// If the tree is empty, returns an error.
+func (t *Tree) Min() (int, error) {
+	if t.root.min == -1 {
+		return 0, fmt.Errorf("found no key in an empty tree")
+	}
+	return t.root.min, nil
+}
+
+// Max returns the largest key.
+// If the tree is empty, returns an error.
+func (t *Tree) Max() (int, error) {
+	if t.root.max == -1 {
+		return 0, fmt.Errorf("found no key in an empty tree")
+	}
+	return t.root.max, nil
+}
+
+// Successor returns the smallest key greater than a given key.
+// If there is no such key, returns an error.
+func (t *Tree) Successor(key int) (int, error) {
+	s := -1
+	switch {
+	case key < 0:
+		s = t.root.min
+	case key < 1<<t.bits-1:
+		s = t.root.successor(key)
+	}
+
+	if s == -1 {
+		return 0, fmt.Errorf("found no key greater than '%v'", key)
+	}
+	return s, nil
+}
+
+// Predecessor returns the largest key less than a given key.
+// If there is no such key, returns an error.
+func (t *Tree) Predecessor(key int) (int, error) {
+	p := -1
+	switch {
+	case key >= 1<<t.bits:
+		p = t.root.max
+	case key > 0:
+		p = t.root.predecessor(key)
+	}
+
+	if p == -1 {
+		return 0, fmt.Errorf("found no key less than '%v'", key)
+	}
+	return p, nil
+}
+
+// Ascend calls f for each key-value pair in ascending order of keys.
+// If f returns false, Ascend stops the iteration.
+func (t *Tree) Ascend(f func(key int, value interface{}) bool) {
+	for k := t.root.min; k != -1; k = t.root.successor(k) {
+		if !f(k, *t.root.find(k)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package veb_test
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/masa-suzu/gtree/veb"
+)
+
+func TestSuccessorPredecessor_Random(t *testing.T) {
+	for _, bits := range []int{1, 2, 5, 8, 13} {
+		tree, err := veb.NewWithBits(bits)
+		if err != nil {
+			t.Fatalf("got an error '%v'", err)
+		}
+		u := 1 << bits
+		present := make([]bool, u)
+		r := rand.New(rand.NewSource(int64(bits)))
+
+		for i := 0; i < 3000; i++ {
+			k := r.Intn(u)
+			if r.Intn(3) == 0 {
+				tree.Delete(k)
+				present[k] = false
+			} else {
+				tree.Insert(k, k)
+				present[k] = true
+			}
+
+			q := r.Intn(u+2) - 1
+			want, wantErr := -1, true
+			for x := q + 1; x < u; x++ {
+				if x >= 0 && present[x] {
+					want, wantErr = x, false
+					break
+				}
+			}
+			got, err := tree.Successor(q)
+			if (err != nil) != wantErr || err == nil && got != want {
+				t.Fatalf("bits %v: successor of %v must be %v, got %v (%v)", bits, q, want, got, err)
+			}
+
+			want, wantErr = -1, true
+			for x := q - 1; x >= 0; x-- {
+				if x < u && present[x] {
+					want, wantErr = x, false
+					break
+				}
+			}
+			got, err = tree.Predecessor(q)
+			if (err != nil) != wantErr || err == nil && got != want {
+				t.Fatalf("bits %v: predecessor of %v must be %v, got %v (%v)", bits, q, want, got, err)
+			}
+		}
+
+		n := 0
+		for _, p := range present {
+			if p {
+				n++
+			}
+		}
+		if n != tree.Count() {
+			t.Errorf("num of nodes must be %v, got %v", n, tree.Count())
+		}
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	tree := veb.New()
+	if _, err := tree.Min(); err == nil {
+		t.Errorf("got no error for an empty tree")
+	}
+
+	for _, k := range []int{1 << 31, 7, 1<<32 - 1, 0} {
+		tree.Insert(k, k)
+	}
+	tree.Delete(0)
+
+	if got, _ := tree.Min(); got != 7 {
+		t.Errorf("want %v, got %v", 7, got)
+	}
+	if got, _ := tree.Max(); got != 1<<32-1 {
+		t.Errorf("want %v, got %v", 1<<32-1, got)
+	}
+}
+
+func TestInsert_OutOfRange(t *testing.T) {
+	tree, _ := veb.NewWithBits(4)
+	tree.Insert(3, 3)
+
+	for _, k := range []int{-1, 16} {
+		tree.Insert(k, k)
+
+		if !errors.Is(tree.Err(), veb.ErrOutOfRange) {
+			t.Errorf("want '%v', got '%v'", veb.ErrOutOfRange, tree.Err())
+		}
+		if _, err := tree.Search(k); err == nil {
+			t.Errorf("found key %v", k)
+		}
+	}
+	if tree.Count() != 1 {
+		t.Errorf("num of nodes must be %v, got %v", 1, tree.Count())
+	}
+}
+
+func TestSearch_Values(t *testing.T) {
+	for _, bits := range []int{1, 2, 5, 13} {
+		tree, _ := veb.NewWithBits(bits)
+		u := 1 << bits
+		want := map[int]int{}
+		r := rand.New(rand.NewSource(int64(bits)))
+
+		for i := 0; i < 3000; i++ {
+			k := r.Intn(u)
+			if r.Intn(3) == 0 {
+				tree.Delete(k)
+				delete(want, k)
+			} else {
+				tree.Insert(k, i)
+				want[k] = i
+			}
+
+			k = r.Intn(u)
+			v, err := tree.Search(k)
+			if w, ok := want[k]; ok != (err == nil) || ok && v != w {
+				t.Fatalf("bits %v: Search(%v) must be %v, got %v (%v)", bits, k, w, v, err)
+			}
+		}
+	}
+}
+
+func TestNewWithBits_Invalid(t *testing.T) {
+	for _, bits := range []int{0, 63} {
+		if _, err := veb.NewWithBits(bits); err == nil {
+			t.Errorf("got no error for %v bits", bits)
+		}
+	}
+}
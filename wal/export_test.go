@@ -0,0 +1,22 @@
+package wal
+
+// FailNextWrite makes the next write to the log write only half of its
+// bytes and fail with err.
+func (l *Log) FailNextWrite(err error) {
+	l.f = &failingFile{file: l.f, err: err}
+}
+
+type failingFile struct {
+	file
+	err error
+}
+
+func (f *failingFile) Write(b []byte) (int, error) {
+	if f.err == nil {
+		return f.file.Write(b)
+	}
+	err := f.err
+	f.err = nil
+	n, _ := f.file.Write(b[:len(b)/2])
+	return n, err
+}
@@ -0,0 +1,316 @@
+/*
+	Package wal makes an in-memory tree durable by appending every Insert
+	and Delete to a checksummed log before applying it.
+
+	A directory opened by Open holds two files. The log holds the operations
+	applied since the last compaction, and the snapshot holds every key-value
+	pair of the tree at that compaction. Open loads the snapshot, replays
+	the log and drops a torn or corrupt tail left by a crash.
+*/
+package wal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/masa-suzu/gtree/internal/codec"
+)
+
+const (
+	logName      = "wal.log"
+	snapshotName = "snapshot"
+
+	opInsert byte = 1
+	opDelete byte = 2
+
+	// headerSize is the size of a record header: a payload length and
+	// a CRC-32C of the payload.
+	headerSize = 8
+)
+
+var table = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrClosed is returned by operations on a closed Log.
+var ErrClosed = errors.New("wal: log is closed")
+
+// Tree is the set of operations that Log needs from the wrapped tree.
+// Both avl.Tree and llrb.Tree implement it.
+type Tree interface {
+	Search(key int) (interface{}, error)
+	Insert(key int, value interface{})
+	Delete(key int)
+	Count() int
+	Ascend(f func(key int, value interface{}) bool)
+}
+
+// Log wraps a Tree and logs its mutations.
+type Log struct {
+	tree Tree
+	dir  string
+	f    file
+	size int64 // size of the log without a torn record
+	buf  []byte
+}
+
+// file is the part of *os.File that Log writes the log through.
+type file interface {
+	io.WriteCloser
+	Sync() error
+	Truncate(size int64) error
+}
+
+// Open opens the log in dir, creating dir if needed, and recovers
+// the contents of tree from it. The given tree must be empty.
+func Open(dir string, tree Tree) (*Log, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := load(filepath.Join(dir, snapshotName), tree, false); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, logName)
+	if err := load(path, tree, true); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &Log{
+		tree: tree,
+		dir:  dir,
+		f:    f,
+		size: fi.Size(),
+	}, nil
+}
+
+// Count returns num of nodes in the wrapped tree.
+func (l *Log) Count() int {
+	return l.tree.Count()
+}
+
+// Search returns a value associated with a given key.
+// If no value is found by the key, returns nil with an error.
+func (l *Log) Search(key int) (interface{}, error) {
+	return l.tree.Search(key)
+}
+
+// Insert logs and then applies the insertion of a value with a given key.
+// The tree is left unchanged if the record cannot be written.
+func (l *Log) Insert(key int, value interface{}) error {
+	if err := l.append(opInsert, key, value); err != nil {
+		return err
+	}
+	l.tree.Insert(key, value)
+	return nil
+}
+
+// Delete logs and then applies the deletion of a given key.
+// The tree is left unchanged if the record cannot be written.
+func (l *Log) Delete(key int) error {
+	if err := l.append(opDelete, key, nil); err != nil {
+		return err
+	}
+	l.tree.Delete(key)
+	return nil
+}
+
+// Compact writes the current contents of the tree to the snapshot
+// and empties the log.
+func (l *Log) Compact() error {
+	if l.f == nil {
+		return ErrClosed
+	}
+
+	tmp := filepath.Join(l.dir, snapshotName+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	l.tree.Ascend(func(key int, value interface{}) bool {
+		b, err = appendRecord(b, opInsert, key, value)
+		return err == nil
+	})
+	if err == nil {
+		_, err = f.Write(b)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, filepath.Join(l.dir, snapshotName)); err != nil {
+		return err
+	}
+	if err := syncDir(l.dir); err != nil {
+		return err
+	}
+
+	// A crash before the truncation only leaves records which are
+	// already in the snapshot, and replaying them is harmless.
+	if err := l.f.Truncate(0); err != nil {
+		return err
+	}
+	l.size = 0
+	return l.f.Sync()
+}
+
+// Close closes the log file. The wrapped tree stays usable.
+func (l *Log) Close() error {
+	if l.f == nil {
+		return ErrClosed
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+func (l *Log) append(op byte, key int, value interface{}) error {
+	if l.f == nil {
+		return ErrClosed
+	}
+
+	b, err := appendRecord(l.buf[:0], op, key, value)
+	if err != nil {
+		return err
+	}
+	l.buf = b
+
+	if _, err := l.f.Write(b); err != nil {
+		return l.rollback(err)
+	}
+	if err := l.f.Sync(); err != nil {
+		return l.rollback(err)
+	}
+	l.size += int64(len(b))
+	return nil
+}
+
+// rollback drops a record which failed to be written, in whole or in
+// part, so that later records are not appended after a torn one and lost
+// on recovery. If the record cannot be dropped, the log is closed.
+func (l *Log) rollback(err error) error {
+	if terr := l.f.Truncate(l.size); terr != nil {
+		l.f.Close()
+		l.f = nil
+		return fmt.Errorf("wal: %v, and dropping the record failed: %v", err, terr)
+	}
+	return err
+}
+
+func appendRecord(b []byte, op byte, key int, value interface{}) ([]byte, error) {
+	start := len(b)
+	b = append(b, make([]byte, headerSize)...)
+	b = append(b, op)
+	b = binary.AppendVarint(b, int64(key))
+
+	if op == opInsert {
+		var err error
+		b, err = codec.AppendValue(b, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	payload := b[start+headerSize:]
+	binary.LittleEndian.PutUint32(b[start:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(b[start+4:], crc32.Checksum(payload, table))
+	return b, nil
+}
+
+// load applies the records in the file at path to tree.
+// When tail is true, a torn or corrupt tail is truncated away;
+// otherwise it is reported as an error.
+func load(path string, tree Tree, tail bool) error {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	off := 0
+	for off < len(b) {
+		n, err := apply(b[off:], tree)
+		if err != nil {
+			if !tail {
+				return fmt.Errorf("wal: %v at offset %v of %v", err, off, path)
+			}
+			return os.Truncate(path, int64(off))
+		}
+		off += n
+	}
+	return nil
+}
+
+var errCorrupt = errors.New("corrupt record")
+
+func apply(b []byte, tree Tree) (int, error) {
+	if len(b) < headerSize {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	size := int(binary.LittleEndian.Uint32(b))
+	if size > len(b)-headerSize {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	payload := b[headerSize : headerSize+size]
+	if crc32.Checksum(payload, table) != binary.LittleEndian.Uint32(b[4:]) {
+		return 0, errCorrupt
+	}
+
+	if len(payload) == 0 {
+		return 0, errCorrupt
+	}
+	key, n := binary.Varint(payload[1:])
+	if n <= 0 {
+		return 0, errCorrupt
+	}
+
+	switch payload[0] {
+	case opInsert:
+		value, _, err := codec.DecodeValue(payload[1+n:])
+		if err != nil {
+			return 0, err
+		}
+		tree.Insert(int(key), value)
+	case opDelete:
+		tree.Delete(int(key))
+	default:
+		return 0, errCorrupt
+	}
+	return headerSize + size, nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
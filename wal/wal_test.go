@@ -0,0 +1,259 @@
+package wal_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/masa-suzu/gtree/avl"
+	"github.com/masa-suzu/gtree/llrb"
+	"github.com/masa-suzu/gtree/wal"
+)
+
+type kv struct {
+	k int
+	v interface{}
+}
+
+type op struct {
+	del bool
+	k   int
+	v   interface{}
+}
+
+var ops = []op{
+	{k: 6, v: 600},
+	{k: 10, v: "1000"},
+	{k: 1, v: 100},
+	{k: 9, v: nil},
+	{del: true, k: 6},
+	{k: 1, v: 1.5},
+	{k: 2, v: true},
+	{del: true, k: 100},
+	{del: true, k: 10},
+	{k: 8, v: []byte("800")},
+}
+
+func TestInsertDelete_Reopen(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := wal.Open(dir, avl.New())
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	for _, o := range ops {
+		apply(t, l, o)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	tree := llrb.New()
+	if _, err := wal.Open(dir, tree); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	assertTree(t, tree, model(ops))
+}
+
+func TestOpen_TruncatedLog(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := wal.Open(dir, avl.New())
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	// ends[i] is the size of the log after ops[i] is written.
+	ends := []int64{}
+	for _, o := range ops {
+		apply(t, l, o)
+		ends = append(ends, size(t, filepath.Join(dir, "wal.log")))
+	}
+	l.Close()
+
+	log, err := os.ReadFile(filepath.Join(dir, "wal.log"))
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+
+	for cut := 0; cut <= len(log); cut++ {
+		crashed := t.TempDir()
+		path := filepath.Join(crashed, "wal.log")
+		if err := os.WriteFile(path, log[:cut], 0644); err != nil {
+			t.Fatalf("got an error '%v'", err)
+		}
+
+		done := 0
+		for done < len(ends) && ends[done] <= int64(cut) {
+			done++
+		}
+
+		tree := avl.New()
+		l, err := wal.Open(crashed, tree)
+		if err != nil {
+			t.Fatalf("cut at %v: got an error '%v'", cut, err)
+		}
+		assertTree(t, tree, model(ops[:done]))
+
+		// The torn tail must be dropped so that new records are readable.
+		if err := l.Insert(1000, 1000); err != nil {
+			t.Fatalf("cut at %v: got an error '%v'", cut, err)
+		}
+		l.Close()
+
+		tree = avl.New()
+		if _, err := wal.Open(crashed, tree); err != nil {
+			t.Fatalf("cut at %v: got an error '%v'", cut, err)
+		}
+		assertTree(t, tree, append(model(ops[:done]), kv{k: 1000, v: 1000}))
+	}
+}
+
+func TestOpen_CorruptRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	l, _ := wal.Open(dir, avl.New())
+	apply(t, l, ops[0])
+	end := size(t, filepath.Join(dir, "wal.log"))
+	apply(t, l, ops[1])
+	apply(t, l, ops[2])
+	l.Close()
+
+	path := filepath.Join(dir, "wal.log")
+	b, _ := os.ReadFile(path)
+	b[end+10] ^= 0xff
+	os.WriteFile(path, b, 0644)
+
+	tree := avl.New()
+	if _, err := wal.Open(dir, tree); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	assertTree(t, tree, model(ops[:1]))
+}
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+
+	l, _ := wal.Open(dir, llrb.New())
+	for _, o := range ops[:5] {
+		apply(t, l, o)
+	}
+	if err := l.Compact(); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	if got := size(t, filepath.Join(dir, "wal.log")); got != 0 {
+		t.Errorf("log must be empty, got %v bytes", got)
+	}
+	for _, o := range ops[5:] {
+		apply(t, l, o)
+	}
+	l.Close()
+
+	tree := avl.New()
+	if _, err := wal.Open(dir, tree); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	assertTree(t, tree, model(ops))
+}
+
+func TestInsert_TornWrite(t *testing.T) {
+	dir := t.TempDir()
+	l, _ := wal.Open(dir, avl.New())
+	l.Insert(1, 100)
+
+	want := errors.New("disk full")
+	l.FailNextWrite(want)
+	if err := l.Insert(2, 200); err != want {
+		t.Fatalf("want '%v', got '%v'", want, err)
+	}
+	// appended after the dropped half of the record
+	if err := l.Insert(3, 300); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	l.Close()
+
+	tree := avl.New()
+	if _, err := wal.Open(dir, tree); err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	assertTree(t, tree, []kv{{k: 1, v: 100}, {k: 3, v: 300}})
+}
+
+func TestClosed(t *testing.T) {
+	l, _ := wal.Open(t.TempDir(), avl.New())
+	l.Close()
+
+	if err := l.Insert(1, 1); err != wal.ErrClosed {
+		t.Errorf("want '%v', got '%v'", wal.ErrClosed, err)
+	}
+	if l.Count() != 0 {
+		t.Errorf("num of nodes must be %v, got %v", 0, l.Count())
+	}
+}
+
+func apply(t *testing.T, l *wal.Log, o op) {
+	t.Helper()
+
+	var err error
+	if o.del {
+		err = l.Delete(o.k)
+	} else {
+		err = l.Insert(o.k, o.v)
+	}
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+}
+
+func model(ops []op) []kv {
+	m := map[int]interface{}{}
+	for _, o := range ops {
+		if o.del {
+			delete(m, o.k)
+		} else {
+			m[o.k] = o.v
+		}
+	}
+
+	kvs := []kv{}
+	for k, v := range m {
+		kvs = append(kvs, kv{k: k, v: v})
+	}
+	return kvs
+}
+
+func size(t *testing.T, path string) int64 {
+	t.Helper()
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("got an error '%v'", err)
+	}
+	return fi.Size()
+}
+
+func assertTree(t *testing.T, tree wal.Tree, kvs []kv) {
+	t.Helper()
+
+	if len(kvs) != tree.Count() {
+		t.Errorf("num of nodes must be %v, got %v", len(kvs), tree.Count())
+	}
+
+	for _, kv := range kvs {
+		got, err := tree.Search(kv.k)
+
+		if err != nil {
+			t.Errorf("got an error '%v'", err)
+		}
+		if b, ok := kv.v.([]byte); ok {
+			if string(b) != string(got.([]byte)) {
+				t.Errorf("want %v, got %v", kv.v, got)
+			}
+			continue
+		}
+		if kv.v != got {
+			t.Errorf("want %v, got %v", kv.v, got)
+		}
+	}
+}
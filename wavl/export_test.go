@@ -0,0 +1,22 @@
+package wavl
+
+// Rotations returns num of rotations done so far.
+func (t *Tree) Rotations() int {
+	return t.rotations
+}
+
+// IsAVL reports whether no node is a 2,2-node, which holds for a tree
+// built by insertions only. Such a tree is an AVL tree.
+func (t *Tree) IsAVL() bool {
+	var walk func(*node) bool
+	walk = func(n *node) bool {
+		if n == nil {
+			return true
+		}
+		if n.rank-rank(n.left) == 2 && n.rank-rank(n.right) == 2 {
+			return false
+		}
+		return walk(n.left) && walk(n.right)
+	}
+	return walk(t.root)
+}
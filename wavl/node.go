@@ -0,0 +1,23 @@
+package wavl
+
+type node struct {
+	rank   int
+	key    int
+	value  interface{}
+	left   *node
+	right  *node
+	parent *node
+}
+
+// rank returns the rank of n. A missing node has rank -1.
+func rank(n *node) int {
+	if n == nil {
+		return -1
+	}
+	return n.rank
+}
+
+// isLeaf reports whether n has no children.
+func (n *node) isLeaf() bool {
+	return n.left == nil && n.right == nil
+}
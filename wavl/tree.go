@@ -0,0 +1,305 @@
+/*
+	Package wavl provides an implementation of Weak AVL Tree.
+	Original implementation is available from https://sidsen.azurewebsites.net/papers/rb-trees-talg.pdf.
+
+	Every node has a rank, and the rank difference between a node and its
+	child is 1 or 2, with leaves at rank 0. Without deletions the tree is
+	an AVL tree. Rebalancing after a deletion does at most two rotations,
+	while the rest of the fixup only changes ranks.
+*/
+package wavl
+
+import (
+	"fmt"
+)
+
+const (
+	lt = -1
+	eq = 0
+	gt = 1
+)
+
+// Tree implements a WAVL tree.
+type Tree struct {
+	root      *node
+	count     int
+	rotations int
+}
+
+// New returns a reference to an empty Tree.
+func New() *Tree {
+	return &Tree{
+		root:  nil,
+		count: 0,
+	}
+}
+
+// Count returns num of nodes.
+func (t *Tree) Count() int {
+	return t.count
+}
+
+// Search returns a value associated with a given key.
+// If no value is found by the key, returns nil with an error.
+func (t *Tree) Search(key int) (interface{}, error) {
+	if x := t.find(key); x != nil {
+		return x.value, nil
+	}
+	return nil, fmt.Errorf("found no value by key '%v'", key)
+}
+
+func (t *Tree) find(key int) *node {
+	x := t.root
+
+	for x != nil {
+		cmp := compare(key, x.key)
+		switch cmp {
+		case eq:
+			return x
+		case lt:
+			x = x.left
+		case gt:
+			x = x.right
+		}
+	}
+	return nil
+}
+
+// Insert a value with a given key.
+// If the same key has already inserted, the new value overrides old one.
+func (t *Tree) Insert(key int, value interface{}) {
+	var p *node
+	link := &t.root
+
+	for *link != nil {
+		p = *link
+		switch compare(key, p.key) {
+		case eq:
+			p.value = value
+			return
+		case lt:
+			link = &p.left
+		case gt:
+			link = &p.right
+		}
+	}
+
+	x := &node{
+		key:    key,
+		value:  value,
+		parent: p,
+	}
+	*link = x
+	t.count++
+	t.insertFixup(x)
+}
+
+// insertFixup removes a rank difference of 0 between x and its parent.
+func (t *Tree) insertFixup(x *node) {
+	for p := x.parent; p != nil && p.rank == x.rank; p = x.parent {
+		s := p.left
+		if s == x {
+			s = p.right
+		}
+
+		// p is a 0,1-node
+		if p.rank-rank(s) == 1 {
+			p.rank++
+			x = p
+			continue
+		}
+
+		// p is a 0,2-node
+		if x == p.left {
+			y := x.right
+			if y == nil || x.rank-y.rank == 2 {
+				t.rotateRight(p)
+				p.rank--
+			} else {
+				t.rotateLeft(x)
+				t.rotateRight(p)
+				y.rank++
+				x.rank--
+				p.rank--
+			}
+		} else {
+			y := x.left
+			if y == nil || x.rank-y.rank == 2 {
+				t.rotateLeft(p)
+				p.rank--
+			} else {
+				t.rotateRight(x)
+				t.rotateLeft(p)
+				y.rank++
+				x.rank--
+				p.rank--
+			}
+		}
+		return
+	}
+}
+
+// Delete remove a node by a given key.
+// If the key does not found, do nothing.
+func (t *Tree) Delete(key int) {
+	z := t.find(key)
+	if z == nil {
+		return
+	}
+	t.count--
+
+	if z.left != nil && z.right != nil {
+		y := min(z.right)
+		z.key, z.value = y.key, y.value
+		z = y
+	}
+
+	// z has at most one child now
+	x := z.left
+	if x == nil {
+		x = z.right
+	}
+	p := z.parent
+	t.replace(z, x)
+
+	if p != nil {
+		t.deleteFixup(x, p)
+	}
+}
+
+// deleteFixup removes a 2,2-leaf at p and rank differences of 3 between
+// x, which may be nil, and its parent p.
+func (t *Tree) deleteFixup(x, p *node) {
+	if p.isLeaf() && p.rank == 1 {
+		p.rank = 0
+		x = p
+		p = p.parent
+	}
+
+	for p != nil && p.rank-rank(x) == 3 {
+		y := p.left
+		if y == x {
+			y = p.right
+		}
+
+		if p.rank-y.rank == 2 {
+			p.rank--
+			x = p
+			p = p.parent
+			continue
+		}
+		if y.rank-rank(y.left) == 2 && y.rank-rank(y.right) == 2 {
+			p.rank--
+			y.rank--
+			x = p
+			p = p.parent
+			continue
+		}
+
+		if y == p.right {
+			z, v := y.right, y.left
+			if y.rank-rank(z) == 1 {
+				t.rotateLeft(p)
+				y.rank++
+				p.rank--
+				if p.isLeaf() {
+					p.rank--
+				}
+			} else {
+				t.rotateRight(y)
+				t.rotateLeft(p)
+				v.rank += 2
+				y.rank--
+				p.rank -= 2
+			}
+		} else {
+			z, v := y.left, y.right
+			if y.rank-rank(z) == 1 {
+				t.rotateRight(p)
+				y.rank++
+				p.rank--
+				if p.isLeaf() {
+					p.rank--
+				}
+			} else {
+				t.rotateLeft(y)
+				t.rotateRight(p)
+				v.rank += 2
+				y.rank--
+				p.rank -= 2
+			}
+		}
+		return
+	}
+}
+
+// replace puts v, which may be nil, in place of u.
+func (t *Tree) replace(u, v *node) {
+	switch {
+	case u.parent == nil:
+		t.root = v
+	case u == u.parent.left:
+		u.parent.left = v
+	default:
+		u.parent.right = v
+	}
+	if v != nil {
+		v.parent = u.parent
+	}
+}
+
+func (t *Tree) rotateLeft(x *node) {
+	t.rotations++
+
+	y := x.right
+	x.right = y.left
+	if y.left != nil {
+		y.left.parent = x
+	}
+	t.replace(x, y)
+	y.left = x
+	x.parent = y
+}
+
+func (t *Tree) rotateRight(x *node) {
+	t.rotations++
+
+	y := x.left
+	x.left = y.right
+	if y.right != nil {
+		y.right.parent = x
+	}
+	t.replace(x, y)
+	y.right = x
+	x.parent = y
+}
+
+func min(n *node) *node {
+	for n.left != nil {
+		n = n.left
+	}
+	return n
+}
+
+// Ascend calls f for each key-value pair in ascending order of keys.
+// If f returns false, Ascend stops the iteration.
+func (t *Tree) Ascend(f func(key int, value interface{}) bool) {
+	ascend(t.root, f)
+}
+
+func ascend(n *node, f func(int, interface{}) bool) bool {
+	if n == nil {
+		return true
+	}
+	return ascend(n.left, f) && f(n.key, n.value) && ascend(n.right, f)
+}
+
+func compare(k1, k2 int) int {
+	if k1 < k2 {
+		return lt
+	}
+	if k1 > k2 {
+		return gt
+	}
+	return eq
+}
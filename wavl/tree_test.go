@@ -0,0 +1,48 @@
+package wavl_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/masa-suzu/gtree/modeltest"
+	"github.com/masa-suzu/gtree/wavl"
+)
+
+func TestDelete_ConstantRotations(t *testing.T) {
+	tree := wavl.New()
+	r := rand.New(rand.NewSource(1))
+	for _, k := range r.Perm(10000) {
+		tree.Insert(k, k)
+	}
+
+	for _, k := range r.Perm(10000) {
+		before := tree.Rotations()
+		tree.Delete(k)
+
+		if got := tree.Rotations() - before; got > 2 {
+			t.Fatalf("deleting %v must rotate at most twice, got %v", k, got)
+		}
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInsert_AVL(t *testing.T) {
+	tree := wavl.New()
+	r := rand.New(rand.NewSource(1))
+	for _, k := range r.Perm(5000) {
+		tree.Insert(k, k)
+	}
+
+	if !tree.IsAVL() {
+		t.Errorf("tree built by insertions only must be an AVL tree")
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInvariants(t *testing.T) {
+	modeltest.Run(t, func() modeltest.KVS { return wavl.New() }, nil)
+}
@@ -0,0 +1,59 @@
+package wavl
+
+import "fmt"
+
+// Validate checks the invariants of the tree: keys are in order, Count
+// matches num of nodes, leaves have rank 0, the rank difference between
+// a node and each of its children is 1 or 2, and parent pointers link
+// children to their parents.
+// It returns an error for the first violation in preorder.
+func (t *Tree) Validate() error {
+	if t.root != nil && t.root.parent != nil {
+		return fmt.Errorf("wavl: root must have no parent")
+	}
+
+	n, err := validate(t.root, nil, nil)
+	if err != nil {
+		return err
+	}
+	if n != t.count {
+		return fmt.Errorf("wavl: Count() is %v, but the tree has %v nodes", t.count, n)
+	}
+	return nil
+}
+
+// validate checks n, whose keys must be in (lo, hi), and returns num of
+// nodes.
+func validate(n *node, lo, hi *int) (int, error) {
+	if n == nil {
+		return 0, nil
+	}
+
+	if lo != nil && n.key <= *lo {
+		return 0, fmt.Errorf("wavl: key %v must be greater than %v", n.key, *lo)
+	}
+	if hi != nil && n.key >= *hi {
+		return 0, fmt.Errorf("wavl: key %v must be less than %v", n.key, *hi)
+	}
+	if n.isLeaf() && n.rank != 0 {
+		return 0, fmt.Errorf("wavl: leaf %v must have rank 0, got %v", n.key, n.rank)
+	}
+	for _, c := range []*node{n.left, n.right} {
+		if d := n.rank - rank(c); d != 1 && d != 2 {
+			return 0, fmt.Errorf("wavl: rank difference under %v must be 1 or 2, got %v", n.key, d)
+		}
+		if c != nil && c.parent != n {
+			return 0, fmt.Errorf("wavl: parent of %v must be %v", c.key, n.key)
+		}
+	}
+
+	l, err := validate(n.left, lo, &n.key)
+	if err != nil {
+		return 0, err
+	}
+	r, err := validate(n.right, &n.key, hi)
+	if err != nil {
+		return 0, err
+	}
+	return 1 + l + r, nil
+}